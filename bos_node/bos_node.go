@@ -1,14 +1,18 @@
 package bos_node
 
+// BOSNode is laid out to fit in 64 bytes on 64-bit platforms: besides the
+// links and the payload it only keeps the subtree size and height.
 type BOSNode struct {
-	LeftChildCount  uint64
-	RightChildCount uint64
-	Depth           uint64
-	LeftChildNode   *BOSNode
-	RightChildNode  *BOSNode
-	ParentNode      *BOSNode
-	Key             interface{}
-	Val             interface{}
+	LeftChildNode  *BOSNode
+	RightChildNode *BOSNode
+	ParentNode     *BOSNode
+	Key            interface{}
+	Val            interface{}
+	// Size is the number of nodes in the subtree rooted here, itself included.
+	Size uint32
+	// Depth is the height of the subtree rooted here, 0 for a leaf. An AVL
+	// tree of 2^32 nodes is less than 50 levels deep.
+	Depth uint8
 }
 
 func (n *BOSNode) HasLeftChild() bool {
@@ -52,34 +56,58 @@ func (n *BOSNode) IsParentRightChild() bool {
 	return false
 }
 
+func (n *BOSNode) LeftChildCount() uint64 {
+	if n.HasLeftChild() {
+		return uint64(n.LeftChildNode.Size)
+	}
+	return 0
+}
+
+func (n *BOSNode) RightChildCount() uint64 {
+	if n.HasRightChild() {
+		return uint64(n.RightChildNode.Size)
+	}
+	return 0
+}
+
 func (n *BOSNode) LeftDepth() uint64 {
 	if n.HasLeftChild() {
-		return n.LeftChildNode.Depth + 1
+		return uint64(n.LeftChildNode.Depth) + 1
 	}
 	return 0
 }
 
 func (n *BOSNode) RightDepth() uint64 {
 	if n.HasRightChild() {
-		return n.RightChildNode.Depth + 1
+		return uint64(n.RightChildNode.Depth) + 1
 	}
 	return 0
 }
 
 func (n *BOSNode) LeftChildDepth() uint64 {
 	if n.HasLeftChild() {
-		return n.LeftChildNode.Depth
+		return uint64(n.LeftChildNode.Depth)
 	}
 	return 0
 }
 
 func (n *BOSNode) RightChildDepth() uint64 {
 	if n.HasRightChild() {
-		return n.RightChildNode.Depth
+		return uint64(n.RightChildNode.Depth)
 	}
 	return 0
 }
 
+// Update recomputes Size and Depth from the children.
+func (n *BOSNode) Update() {
+	n.Size = uint32(n.LeftChildCount() + n.RightChildCount() + 1)
+	if l, r := n.LeftDepth(), n.RightDepth(); l > r {
+		n.Depth = uint8(l)
+	} else {
+		n.Depth = uint8(r)
+	}
+}
+
 func NewNode() *BOSNode {
-	return new(BOSNode)
+	return &BOSNode{Size: 1}
 }
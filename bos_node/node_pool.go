@@ -0,0 +1,49 @@
+package bos_node
+
+const DefaultSlabSize = 1024
+
+// NodePool carves BOSNodes out of slab chunks and recycles released nodes
+// through a free list, linked through their ParentNode fields.
+//
+// A NodePool is not safe for concurrent use.
+type NodePool struct {
+	SlabSize int
+	slab     []BOSNode
+	free     *BOSNode
+}
+
+func NewNodePool(slabSize int) *NodePool {
+	if slabSize <= 0 {
+		slabSize = DefaultSlabSize
+	}
+	return &NodePool{SlabSize: slabSize}
+}
+
+// Get returns a fresh single node, reusing a released one if there is any.
+func (p *NodePool) Get() *BOSNode {
+	if p.free != nil {
+		var n = p.free
+		p.free = n.ParentNode
+		n.ParentNode = nil
+		n.Size = 1
+		return n
+	}
+	if len(p.slab) == 0 {
+		var size = p.SlabSize
+		if size <= 0 {
+			size = DefaultSlabSize
+		}
+		p.slab = make([]BOSNode, size)
+	}
+	var n = &p.slab[0]
+	p.slab = p.slab[1:]
+	n.Size = 1
+	return n
+}
+
+// Put zeroes n and keeps it for the next Get. n must not be used afterwards.
+func (p *NodePool) Put(n *BOSNode) {
+	*n = BOSNode{}
+	n.ParentNode = p.free
+	p.free = n
+}
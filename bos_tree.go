@@ -3,36 +3,38 @@ package bostree
 import (
 	"errors"
 	"fmt"
+	"math"
+	"unsafe"
+
 	. "github.com/bostree/bos_node"
-	"github.com/bostree/ex_math"
 )
 
 type BOSTree struct {
 	RootNode *BOSNode
 	CmpFunc  func(k1, k2 interface{}) int
+	// Pool, if set, allocates the nodes of Insert and takes back the nodes
+	// of Remove. Nodes must not be used after they have been removed.
+	Pool *NodePool
+	// CheckCmp makes the tree check every comparison it makes for
+	// antisymmetry and consistency, and recover CmpFunc panics. Problems are
+	// reported by CmpErr. Trees built with -tags bostree_debug check by
+	// default.
+	CheckCmp bool
+	// cmpErr holds the first *CmpError. Read-only operations compare keys
+	// too, so it is set atomically to keep concurrent readers race-free.
+	cmpErr unsafe.Pointer
+	// Augment, if set, recomputes per-subtree data of a node, usually kept
+	// in its Val, from the node and its children. It is called bottom-up for
+	// every node whose subtree changes, after Size and Depth are updated.
+	Augment func(node *BOSNode)
+	// distinct, once TrackDistinct is called, holds every key once with its
+	// number of nodes, for RankDense.
+	distinct *Multiset
 }
 
 // helper functions
 func BOSTreeBalance(node *BOSNode) int64 {
-	var (
-		leftDepth  uint64
-		rightDepth uint64
-	)
-	if node.HasLeftChild() {
-		leftDepth = node.LeftChildNode.Depth + 1
-	} else {
-		leftDepth = 0
-	}
-	if node.HasRightChild() {
-		rightDepth = node.RightChildNode.Depth + 1
-	} else {
-		rightDepth = 0
-	}
-	if rightDepth > leftDepth {
-		return int64(rightDepth) - int64(leftDepth)
-	} else {
-		return -int64((leftDepth - rightDepth))
-	}
+	return int64(node.RightDepth()) - int64(node.LeftDepth())
 }
 
 // Rotate right:
@@ -60,38 +62,17 @@ func BOSTreeRotateRight(tree *BOSTree, p *BOSNode) *BOSNode {
 	ln.ParentNode = p.ParentNode
 
 	p.LeftChildNode = ln.RightChildNode
-	p.LeftChildCount = ln.RightChildCount
 
 	if p.HasLeftChild() {
 		p.LeftChildNode.ParentNode = p
 	}
 
-	p.Depth = ex_math.Uint64Max(func() uint64 {
-		if p.HasLeftChild() {
-			return p.LeftChildNode.Depth + 1
-		}
-		return 0
-	}(), func() uint64 {
-		if p.HasRightChild() {
-			return p.RightChildNode.Depth + 1
-		}
-		return 0
-	}())
-
 	p.ParentNode = ln
 	ln.RightChildNode = p
-	ln.RightChildCount = p.LeftChildCount + p.RightChildCount + 1
-	ln.Depth = ex_math.Uint64Max(func() uint64 {
-		if ln.HasLeftChild() {
-			return ln.LeftChildNode.Depth + 1
-		}
-		return 0
-	}(), func() uint64 {
-		if ln.HasRightChild() {
-			return ln.RightChildNode.Depth + 1
-		}
-		return 0
-	}())
+
+	// P is now below L, so it has to be updated first.
+	tree.update(p)
+	tree.update(ln)
 
 	return ln
 }
@@ -119,119 +100,88 @@ func BOSTreeRotateLeft(tree *BOSTree, p *BOSNode) *BOSNode {
 	rn.ParentNode = p.ParentNode
 
 	p.RightChildNode = rn.LeftChildNode
-	p.RightChildCount = rn.LeftChildCount
 
 	if p.HasRightChild() {
 		p.RightChildNode.ParentNode = p
 	}
 
-	p.Depth = ex_math.Uint64Max(func() uint64 {
-		if p.HasLeftChild() {
-			return p.LeftChildNode.Depth + 1
-		}
-		return 0
-	}(), func() uint64 {
-		if p.HasRightChild() {
-			return p.RightChildNode.Depth + 1
-		}
-		return 0
-	}())
-
 	p.ParentNode = rn
 	rn.LeftChildNode = p
-	rn.LeftChildCount = p.LeftChildCount + p.RightChildCount + 1
-	rn.Depth = ex_math.Uint64Max(func() uint64 {
-		if rn.HasLeftChild() {
-			return rn.LeftChildNode.Depth + 1
-		}
-		return 0
-	}(), func() uint64 {
-		if rn.HasRightChild() {
-			return rn.RightChildNode.Depth + 1
-		}
-		return 0
-	}())
+
+	tree.update(p)
+	tree.update(rn)
 
 	return rn
 }
 
+// BOSTreeRebalance restores the AVL property at node, whose children must
+// already be balanced, and returns the root of the rebalanced subtree.
+func BOSTreeRebalance(tree *BOSTree, node *BOSNode) *BOSNode {
+	tree.update(node)
+	balance := BOSTreeBalance(node)
+	if balance < -1 {
+		// Rotate right. Check for left-right case before.
+		if BOSTreeBalance(node.LeftChildNode) > 0 {
+			BOSTreeRotateLeft(tree, node.LeftChildNode)
+		}
+		return BOSTreeRotateRight(tree, node)
+	} else if balance > 1 {
+		if BOSTreeBalance(node.RightChildNode) < 0 {
+			BOSTreeRotateRight(tree, node.RightChildNode)
+		}
+		return BOSTreeRotateLeft(tree, node)
+	}
+	return node
+}
+
 func (tree *BOSTree) Insert(key, val interface{}) *BOSNode {
 	var (
 		node       *BOSNode = tree.RootNode
 		parentNode *BOSNode = nil
-		newNode    *BOSNode = NewNode()
+		cmp        int
 	)
 
-	newNode.Key = key
-	newNode.Val = val
+	if tree.NodeCount() == math.MaxUint32 {
+		panic("bostree: tree is full")
+	}
+	if tree.distinct != nil {
+		tree.distinct.Add(key, 1)
+	}
 
 	for node != nil {
 		parentNode = node
-		cmp := tree.CmpFunc(key, node.Key)
+		cmp = tree.cmp(key, node.Key)
+		node.Size++
 		if cmp < 0 {
 			// go into left subtree
-			node.LeftChildCount++
 			node = node.LeftChildNode
 		} else {
 			// go into right subtree
-			node.RightChildCount++
 			node = node.RightChildNode
 		}
 	}
 
+	var newNode = tree.newNode()
+	newNode.Key = key
+	newNode.Val = val
+
 	if parentNode == nil {
 		// this is the first node
 		tree.RootNode = newNode
+		tree.update(newNode)
 		return newNode
 	}
 
-	parentNode.Depth++
-
-	cmp := tree.CmpFunc(parentNode.Key, newNode.Key)
-
 	if cmp < 0 {
-		parentNode.RightChildNode = newNode
-		newNode.ParentNode = parentNode
-	} else {
 		parentNode.LeftChildNode = newNode
-		newNode.ParentNode = parentNode
+	} else {
+		parentNode.RightChildNode = newNode
 	}
-	for parentNode.HasParent() {
-		parentNode = parentNode.ParentNode
-		var (
-			newLeftDepth = func() uint64 {
-				if parentNode.HasLeftChild() {
-					return parentNode.LeftChildNode.Depth + 1
-				}
-				return 0
-			}()
-			newRightDepth = func() uint64 {
-				if parentNode.HasRightChild() {
-					return parentNode.RightChildNode.Depth + 1
-				}
-				return 0
-			}()
-			maxDepth = ex_math.Uint64Max(newLeftDepth, newRightDepth)
-		)
+	newNode.ParentNode = parentNode
+	tree.update(newNode)
 
-		if parentNode.Depth != maxDepth {
-			parentNode.Depth = maxDepth
-		} else {
-			break
-		}
-
-		if newLeftDepth-2 == newRightDepth {
-			if BOSTreeBalance(parentNode.LeftChildNode) > 0 {
-				BOSTreeRotateLeft(tree, parentNode.LeftChildNode)
-			}
-			parentNode = BOSTreeRotateRight(tree, parentNode)
-		} else if newLeftDepth+2 == newRightDepth {
-			if BOSTreeBalance(parentNode.RightChildNode) < 0 {
-				BOSTreeRotateRight(tree, parentNode.RightChildNode)
-			}
-			parentNode = BOSTreeRotateLeft(tree, parentNode)
-		}
-	}
+	// Sizes have been counted on the way down already.
+	tree.bubbleUp(parentNode, 0)
 
 	return newNode
 }
@@ -240,6 +190,9 @@ func (tree *BOSTree) Remove(node *BOSNode) {
 	var (
 		bubbleUp *BOSNode
 	)
+	if tree.distinct != nil {
+		tree.distinct.RemoveN(node.Key, 1)
+	}
 
 	// If this node has children on both sides, bubble one of it upwards
 	// and rotate within the subtrees.
@@ -253,18 +206,14 @@ func (tree *BOSTree) Remove(node *BOSNode) {
 			// Left branch is deeper than right branch, might be a good idea to
 			// bubble from this side to maintain the AVL property with increased
 			// likelihood.
-			node.LeftChildCount--
 			candidate = node.LeftChildNode
 			for candidate.HasRightChild() {
-				candidate.RightChildCount--
 				candidate = candidate.RightChildNode
 			}
 			lostChild = candidate.LeftChildNode
 		} else {
-			node.RightChildCount--
 			candidate = node.RightChildNode
 			for candidate.HasLeftChild() {
-				candidate.LeftChildCount--
 				candidate = candidate.LeftChildNode
 			}
 			lostChild = candidate.RightChildNode
@@ -282,24 +231,16 @@ func (tree *BOSTree) Remove(node *BOSNode) {
 			lostChild.ParentNode = bubbleStart
 		}
 
-		// We will later rebalance upwards from bubbleStart up to candidate.
+		// We will later rebalance upwards from bubbleStart.
 		// But first, anchor candidate into the place where "node" used to be.
-		if node.HasParent() {
-			if node.IsParentLeftChild() {
-				node.ParentNode.LeftChildNode = candidate
-			} else {
-				node.ParentNode.RightChildNode = candidate
-			}
-		} else {
-			tree.RootNode = candidate
-		}
+		tree.replaceChild(node, candidate)
 
 		// Node transplant
 		candidate.ParentNode = node.ParentNode
 		candidate.LeftChildNode = node.LeftChildNode
-		candidate.LeftChildCount = node.LeftChildCount
 		candidate.RightChildNode = node.RightChildNode
-		candidate.RightChildCount = node.RightChildCount
+		candidate.Size = node.Size
+		candidate.Depth = node.Depth
 
 		if candidate.HasLeftChild() {
 			candidate.LeftChildNode.ParentNode = candidate
@@ -309,148 +250,80 @@ func (tree *BOSTree) Remove(node *BOSNode) {
 		}
 
 		// From here on, node is out of the game.
-		// Rebalance up to candidate.
-
-		if bubbleStart != node {
-			for bubbleStart != candidate {
-				bubbleStart.Depth = ex_math.Uint64Max(
-					func() uint64 {
-						if bubbleStart.HasLeftChild() {
-							return bubbleStart.LeftChildNode.Depth + 1
-						}
-						return 0
-					}(),
-					func() uint64 {
-						if bubbleStart.HasRightChild() {
-							return bubbleStart.RightChildNode.Depth + 1
-						}
-						return 0
-					}(),
-				)
-				balance := BOSTreeBalance(bubbleStart)
-				if balance > 1 {
-					// Rotate left. Check for right-left case before.
-					if BOSTreeBalance(bubbleStart.RightChildNode) < 0 {
-						BOSTreeRotateRight(tree, bubbleStart.RightChildNode)
-					}
-					bubbleStart = BOSTreeRotateLeft(tree, bubbleStart)
-				} else if balance < -1 {
-					if BOSTreeBalance(bubbleStart.LeftChildNode) > 0 {
-						BOSTreeRotateLeft(tree, bubbleStart.LeftChildNode)
-					}
-					bubbleStart = BOSTreeRotateRight(tree, bubbleStart)
-				}
-				bubbleStart = bubbleStart.ParentNode
-			}
-		}
-
-		candidate.Depth = ex_math.Uint64Max(
-			func() uint64 {
-				if candidate.HasLeftChild() {
-					return candidate.LeftChildNode.Depth + 1
-				}
-				return 0
-			}(),
-			func() uint64 {
-				if candidate.HasRightChild() {
-					return candidate.RightChildNode.Depth + 1
-				}
-				return 0
-			}())
-
-		bubbleUp = candidate.ParentNode
-
-		if bubbleUp != nil {
-			if candidate.IsParentLeftChild() {
-				bubbleUp.LeftChildCount--
-			} else {
-				bubbleUp.RightChildCount--
-			}
+		if bubbleStart == node {
+			bubbleUp = candidate
+		} else {
+			bubbleUp = bubbleStart
 		}
 	} else {
 		// This node has children on only one side
-		if !node.HasParent() {
-			if node.HasLeftChild() {
-				tree.RootNode = node.LeftChildNode
-				node.LeftChildNode.ParentNode = nil
-			} else {
-				tree.RootNode = node.RightChildNode
-				if node.HasRightChild() {
-					node.RightChildNode.ParentNode = nil
-				}
-			}
+		var candidate = node.LeftChildNode
+		if node.HasRightChild() {
+			candidate = node.RightChildNode
+		}
 
-			bubbleUp = nil
-		} else {
-			var (
-				candidate      *BOSNode = node.LeftChildNode
-				candidateCount uint64   = node.LeftChildCount
-			)
-
-			if node.HasRightChild() {
-				candidate = node.RightChildNode
-				candidateCount = node.RightChildCount
-			}
+		tree.replaceChild(node, candidate)
 
-			if node.IsParentLeftChild() {
-				node.ParentNode.LeftChildNode = candidate
-				node.ParentNode.LeftChildCount = candidateCount
-			} else {
-				node.ParentNode.RightChildNode = candidate
-				node.ParentNode.RightChildCount = candidateCount
-			}
+		if candidate != nil {
+			candidate.ParentNode = node.ParentNode
+		}
 
-			if candidate != nil {
-				candidate.ParentNode = node.ParentNode
-			}
+		bubbleUp = node.ParentNode
+	}
 
-			bubbleUp = node.ParentNode
-		}
+	tree.bubbleUp(bubbleUp, -1)
+
+	if tree.Pool != nil {
+		tree.Pool.Put(node)
 	}
+}
 
-	var bubbleUpFinished = false
-
-	for bubbleUp != nil {
-		if !bubbleUpFinished {
-			var (
-				leftDepth    = bubbleUp.LeftDepth()
-				rightDepth   = bubbleUp.RightDepth()
-				newDepth     = ex_math.Uint64Max(leftDepth, rightDepth)
-				depthChanged = newDepth != bubbleUp.Depth
-			)
-
-			bubbleUp.Depth = newDepth
-
-			// Rebalance bubble_up
-			// Not necessary for the first node, but calling BOSTreeBalance once
-			// isn't that much overhead.
-			balance := BOSTreeBalance(bubbleUp)
-
-			if balance < -1 {
-				if BOSTreeBalance(bubbleUp.LeftChildNode) > 0 {
-					BOSTreeRotateLeft(tree, bubbleUp.LeftChildNode)
-				}
-				bubbleUp = BOSTreeRotateRight(tree, bubbleUp)
-			} else if balance > 1 {
-				if BOSTreeBalance(bubbleUp.RightChildNode) < 0 {
-					BOSTreeRotateRight(tree, bubbleUp.RightChildNode)
-				}
-				bubbleUp = BOSTreeRotateLeft(tree, bubbleUp)
-			} else {
-				if !depthChanged {
-					bubbleUpFinished = true
-				}
-			}
-		}
+// update recomputes node from its children.
+func (tree *BOSTree) update(node *BOSNode) {
+	node.Update()
+	if tree.Augment != nil {
+		tree.Augment(node)
+	}
+}
 
-		if bubbleUp.HasParent() {
-			if bubbleUp.IsParentLeftChild() {
-				bubbleUp.ParentNode.LeftChildCount--
-			} else {
-				bubbleUp.ParentNode.RightChildCount--
-			}
+// bubbleUp rebalances from node upwards after the subtree below it has
+// changed. Once a subtree keeps its root and depth, the ancestors are balanced
+// already and only their sizes have to change by delta, and their
+// augmentation has to be recomputed.
+func (tree *BOSTree) bubbleUp(node *BOSNode, delta int64) {
+	for node != nil {
+		var (
+			depth = node.Depth
+			top   = BOSTreeRebalance(tree, node)
+		)
+		if top == node && top.Depth == depth {
+			node = node.ParentNode
+			break
 		}
-		bubbleUp = bubbleUp.ParentNode
+		node = top.ParentNode
+	}
+	if tree.Augment != nil {
+		for ; node != nil; node = node.ParentNode {
+			tree.update(node)
+		}
+		return
+	}
+	if delta == 0 {
+		return
+	}
+	for ; node != nil; node = node.ParentNode {
+		node.Size = uint32(int64(node.Size) + delta)
+	}
+}
+
+// replaceChild puts child where node hangs in the tree.
+func (tree *BOSTree) replaceChild(node, child *BOSNode) {
+	if !node.HasParent() {
+		tree.RootNode = child
+	} else if node.IsParentLeftChild() {
+		node.ParentNode.LeftChildNode = child
+	} else {
+		node.ParentNode.RightChildNode = child
 	}
 }
 
@@ -460,7 +333,7 @@ func (tree *BOSTree) LookUp(key interface{}) *BOSNode {
 	)
 
 	for node != nil {
-		cmp := tree.CmpFunc(key, node.Key)
+		cmp := tree.cmp(key, node.Key)
 		if cmp == 0 {
 			break
 		} else if cmp < 0 {
@@ -477,8 +350,8 @@ func (tree *BOSTree) Select(index uint64) *BOSNode {
 		node *BOSNode = tree.RootNode
 	)
 	for node != nil {
-		if node.LeftChildCount <= index {
-			index -= node.LeftChildCount
+		if leftCount := node.LeftChildCount(); leftCount <= index {
+			index -= leftCount
 			if index == 0 {
 				return node
 			}
@@ -493,11 +366,11 @@ func (tree *BOSTree) Select(index uint64) *BOSNode {
 
 func (tree *BOSTree) Rank(node *BOSNode) uint64 {
 	var (
-		counter = node.LeftChildCount
+		counter = node.LeftChildCount()
 	)
 	for node != nil {
 		if node.HasParent() && node.IsParentRightChild() {
-			counter += 1 + node.ParentNode.LeftChildCount
+			counter += 1 + node.ParentNode.LeftChildCount()
 		}
 		node = node.ParentNode
 	}
@@ -536,6 +409,16 @@ func (tree *BOSTree) PrevNode(node *BOSNode) *BOSNode {
 	return nil
 }
 
+// Ascend calls fn for the nodes from position from onwards, in order, until
+// fn returns false.
+func (tree *BOSTree) Ascend(from uint64, fn func(key, val interface{}) bool) {
+	for node := tree.Select(from); node != nil; node = tree.NxtNode(node) {
+		if !fn(node.Key, node.Val) {
+			return
+		}
+	}
+}
+
 func (tree *BOSTree) PrevValue(key interface{}) (interface{}, error) {
 	var (
 		node       = tree.LookUp(key)
@@ -570,7 +453,7 @@ func (tree *BOSTree) NxtValue(key interface{}) (interface{}, error) {
 
 func (tree *BOSTree) NodeCount() uint64 {
 	if tree.RootNode != nil {
-		return tree.RootNode.LeftChildCount + tree.RootNode.RightChildCount + 1
+		return uint64(tree.RootNode.Size)
 	}
 	return 0
 }
@@ -578,16 +461,31 @@ func (tree *BOSTree) NodeCount() uint64 {
 func Build(cmp_func func(k1, k2 interface{}) int) *BOSTree {
 	var tree = new(BOSTree)
 	tree.CmpFunc = cmp_func
+	tree.CheckCmp = checkCmpDefault
 	return tree
 }
 
+// BuildWithPool builds a tree whose nodes come from, and return to, pool.
+func BuildWithPool(cmp_func func(k1, k2 interface{}) int, pool *NodePool) *BOSTree {
+	var tree = Build(cmp_func)
+	tree.Pool = pool
+	return tree
+}
+
+func (tree *BOSTree) newNode() *BOSNode {
+	if tree.Pool != nil {
+		return tree.Pool.Get()
+	}
+	return NewNode()
+}
+
 func PrintTree(node *BOSNode) {
 	fmt.Printf(
 		"%s(%f) [Left: %d/Right: %d/Depth: %d]\n",
 		node.Val,
 		node.Key,
-		node.LeftChildCount,
-		node.RightChildCount,
+		node.LeftChildCount(),
+		node.RightChildCount(),
 		node.Depth,
 	)
 
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	. "github.com/bostree/bos_node"
 	"github.com/bostree/ex_math"
+	"runtime"
 	"testing"
 	"time"
+	"unsafe"
 )
 
 func TestTreeSanity(t *testing.T) {
@@ -108,7 +110,7 @@ func TestTreeSanity(t *testing.T) {
 
 		t.Run("Depth", func(t *testing.T) {
 
-			if depth != node.Depth {
+			if depth != uint64(node.Depth) {
 				t.Errorf(
 					"Expected %d, but got %d\n",
 					depth,
@@ -121,11 +123,11 @@ func TestTreeSanity(t *testing.T) {
 
 			if node.HasLeftChild() {
 				leftCount := actualCount(node.LeftChildNode)
-				if leftCount != node.LeftChildCount {
+				if leftCount != node.LeftChildCount() {
 					t.Errorf(
 						"Expected %d, but got %d\n",
 						leftCount,
-						node.LeftChildCount,
+						node.LeftChildCount(),
 					)
 				}
 			}
@@ -135,11 +137,11 @@ func TestTreeSanity(t *testing.T) {
 
 			if node.HasRightChild() {
 				rightCount := actualCount(node.RightChildNode)
-				if rightCount != node.RightChildCount {
+				if rightCount != node.RightChildCount() {
 					t.Errorf(
 						"Expected %d, but got %d\n",
 						rightCount,
-						node.RightChildCount,
+						node.RightChildCount(),
 					)
 				}
 			}
@@ -227,6 +229,57 @@ func TestProfiling(t *testing.T) {
 	})
 }
 
+func TestNodeSize(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("node layout is only checked on 64-bit platforms")
+	}
+	if size := unsafe.Sizeof(BOSNode{}); size > 64 {
+		t.Errorf("Expected <=64 bytes, but got %d\n", size)
+	}
+}
+
+// BenchmarkMemoryPerElement reports the heap bytes a tree takes per element.
+// Keys and values are boxed beforehand, so only the nodes are counted.
+func BenchmarkMemoryPerElement(b *testing.B) {
+	const size = 100000
+	var (
+		keys = make([]interface{}, size)
+		vals = make([]interface{}, size)
+	)
+	for i := 0; i < size; i++ {
+		keys[i] = float64(i)
+		vals[i] = fmt.Sprintf("p%d", i)
+	}
+
+	for _, pooled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("Pooled=%v", pooled), func(b *testing.B) {
+			var (
+				before, after runtime.MemStats
+				total         uint64
+			)
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				tree := Build(func(k1, k2 interface{}) int {
+					return int(k1.(float64) - k2.(float64))
+				})
+				if pooled {
+					tree.Pool = NewNodePool(DefaultSlabSize)
+				}
+				for j := 0; j < size; j++ {
+					tree.Insert(keys[j], vals[j])
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				total += after.HeapAlloc - before.HeapAlloc
+				runtime.KeepAlive(tree)
+			}
+			b.ReportMetric(float64(total)/float64(b.N)/size, "B/elem")
+			b.ReportMetric(float64(unsafe.Sizeof(BOSNode{})), "B/node")
+		})
+	}
+}
+
 func actualDepth(node *BOSNode) uint64 {
 	var (
 		leftDepth = func() uint64 {
@@ -0,0 +1,416 @@
+// Package bplus_tree is an order-statistic B+tree: a read-friendly
+// alternative to the AVL BOSTree with wide nodes and per-child subtree
+// counts.
+//
+// Entries live in linked leaves; inner nodes keep separator keys and the
+// number of entries below each child, so Select and Rank take O(log n) steps
+// over contiguous arrays instead of chasing a pointer per level. Duplicate
+// keys are allowed, and, as in BOSTree, a new entry goes after the equal
+// ones already stored.
+package bplus_tree
+
+import (
+	"github.com/bostree"
+)
+
+const DefaultDegree = 64
+
+type node struct {
+	leaf bool
+	keys []interface{}
+	// leaves only
+	vals []interface{}
+	next *node
+	// inner nodes only: len(children) == len(keys)+1, and keys[i] lies
+	// between the largest key of children[i] and the smallest of
+	// children[i+1].
+	children []*node
+	counts   []uint64
+}
+
+type BPlusTree struct {
+	CmpFunc func(k1, k2 interface{}) int
+	root    *node
+	degree  int
+	count   uint64
+}
+
+var _ bostree.OrderedIndex = (*BPlusTree)(nil)
+
+func Build(cmp_func func(k1, k2 interface{}) int) *BPlusTree {
+	return BuildWithDegree(cmp_func, DefaultDegree)
+}
+
+// BuildWithDegree builds a tree whose nodes hold up to degree keys.
+func BuildWithDegree(cmp_func func(k1, k2 interface{}) int, degree int) *BPlusTree {
+	if degree < 4 {
+		degree = 4
+	}
+	return &BPlusTree{
+		CmpFunc: cmp_func,
+		root:    &node{leaf: true},
+		degree:  degree,
+	}
+}
+
+func (tree *BPlusTree) NodeCount() uint64 {
+	return tree.count
+}
+
+func (tree *BPlusTree) Len() uint64 {
+	return tree.count
+}
+
+// Insert adds an entry after all entries with an equal key.
+func (tree *BPlusTree) Insert(key, val interface{}) {
+	right, sep := tree.insert(tree.root, key, val)
+	if right != nil {
+		var left = tree.root
+		tree.root = &node{
+			keys:     []interface{}{sep},
+			children: []*node{left, right},
+			counts:   []uint64{left.size(), right.size()},
+		}
+	}
+	tree.count++
+}
+
+// Remove removes the first entry with an equal key and reports whether there
+// was one.
+func (tree *BPlusTree) Remove(key interface{}) bool {
+	if !tree.remove(tree.root, key) {
+		return false
+	}
+	tree.count--
+	if !tree.root.leaf && len(tree.root.children) == 1 {
+		tree.root = tree.root.children[0]
+	}
+	return true
+}
+
+// LookUp returns the value of the first entry with an equal key.
+func (tree *BPlusTree) LookUp(key interface{}) (interface{}, bool) {
+	var n = tree.root
+	for !n.leaf {
+		n = n.children[tree.search(n.keys, key, false)]
+	}
+	var i = tree.search(n.keys, key, false)
+	if i == len(n.keys) && n.next != nil {
+		// Every key here is smaller; an equal one can only start the next leaf.
+		n, i = n.next, 0
+	}
+	if i == len(n.keys) || tree.CmpFunc(n.keys[i], key) != 0 {
+		return nil, false
+	}
+	return n.vals[i], true
+}
+
+// Select returns the entry at 0-based position index.
+func (tree *BPlusTree) Select(index uint64) (key, val interface{}, ok bool) {
+	n, i := tree.leafAt(index)
+	if n == nil {
+		return nil, nil, false
+	}
+	return n.keys[i], n.vals[i], true
+}
+
+// Ascend calls fn for the entries from position from onwards, following the
+// leaf chain, until fn returns false.
+func (tree *BPlusTree) Ascend(from uint64, fn func(key, val interface{}) bool) {
+	n, i := tree.leafAt(from)
+	for ; n != nil; n, i = n.next, 0 {
+		for ; i < len(n.keys); i++ {
+			if !fn(n.keys[i], n.vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+// leafAt returns the leaf holding position index and the offset within it.
+func (tree *BPlusTree) leafAt(index uint64) (*node, int) {
+	if index >= tree.count {
+		return nil, 0
+	}
+	var n = tree.root
+	for !n.leaf {
+		var i = 0
+		for index >= n.counts[i] {
+			index -= n.counts[i]
+			i++
+		}
+		n = n.children[i]
+	}
+	return n, int(index)
+}
+
+// Rank returns the number of entries whose key is less than key, which is
+// the position of the first entry with an equal key if there is one.
+func (tree *BPlusTree) Rank(key interface{}) uint64 {
+	var (
+		n    = tree.root
+		rank uint64
+	)
+	for !n.leaf {
+		var i = tree.search(n.keys, key, false)
+		for j := 0; j < i; j++ {
+			rank += n.counts[j]
+		}
+		n = n.children[i]
+	}
+	return rank + uint64(tree.search(n.keys, key, false))
+}
+
+// search returns the number of keys less than key, or less than or equal to
+// key if upper is set.
+func (tree *BPlusTree) search(keys []interface{}, key interface{}, upper bool) int {
+	var lo, hi = 0, len(keys)
+	for lo < hi {
+		var (
+			mid = int(uint(lo+hi) >> 1)
+			cmp = tree.CmpFunc(keys[mid], key)
+		)
+		if cmp < 0 || (upper && cmp == 0) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// insert adds the entry below n. If n had to split, it returns the new right
+// sibling and the separator key to put in front of it.
+func (tree *BPlusTree) insert(n *node, key, val interface{}) (*node, interface{}) {
+	var i = tree.search(n.keys, key, true)
+	if n.leaf {
+		n.keys = insertAt(n.keys, i, key)
+		n.vals = insertAt(n.vals, i, val)
+		if len(n.keys) <= tree.degree {
+			return nil, nil
+		}
+		var (
+			mid   = len(n.keys) / 2
+			right = &node{
+				leaf: true,
+				keys: append([]interface{}(nil), n.keys[mid:]...),
+				vals: append([]interface{}(nil), n.vals[mid:]...),
+				next: n.next,
+			}
+		)
+		clearTail(n.keys, mid)
+		clearTail(n.vals, mid)
+		n.keys, n.vals, n.next = n.keys[:mid], n.vals[:mid], right
+		return right, right.keys[0]
+	}
+
+	var child = n.children[i]
+	right, sep := tree.insert(child, key, val)
+	if right == nil {
+		n.counts[i]++
+		return nil, nil
+	}
+	n.keys = insertAt(n.keys, i, sep)
+	n.children = insertNodeAt(n.children, i+1, right)
+	n.counts = insertCountAt(n.counts, i+1, right.size())
+	n.counts[i] = child.size()
+	if len(n.keys) <= tree.degree {
+		return nil, nil
+	}
+
+	// The middle separator moves up; it divides the two halves.
+	var (
+		mid   = len(n.keys) / 2
+		upKey = n.keys[mid]
+		inner = &node{
+			keys:     append([]interface{}(nil), n.keys[mid+1:]...),
+			children: append([]*node(nil), n.children[mid+1:]...),
+			counts:   append([]uint64(nil), n.counts[mid+1:]...),
+		}
+	)
+	clearTail(n.keys, mid)
+	for j := mid + 1; j < len(n.children); j++ {
+		n.children[j] = nil
+	}
+	n.keys, n.children, n.counts = n.keys[:mid], n.children[:mid+1], n.counts[:mid+1]
+	return inner, upKey
+}
+
+// remove removes one entry equal to key below n and fixes up underfull
+// children on the way back.
+func (tree *BPlusTree) remove(n *node, key interface{}) bool {
+	var i = tree.search(n.keys, key, false)
+	if n.leaf {
+		if i == len(n.keys) || tree.CmpFunc(n.keys[i], key) != 0 {
+			return false
+		}
+		n.keys = deleteAt(n.keys, i)
+		n.vals = deleteAt(n.vals, i)
+		return true
+	}
+
+	var found = tree.remove(n.children[i], key)
+	if !found && i < len(n.keys) && tree.CmpFunc(n.keys[i], key) == 0 {
+		// Equal keys may continue at the start of the next child.
+		i++
+		found = tree.remove(n.children[i], key)
+	}
+	if !found {
+		return false
+	}
+	n.counts[i]--
+	tree.rebalance(n, i)
+	return true
+}
+
+// rebalance refills children[i] of n from a sibling, or merges it into one,
+// if it has become less than half full.
+func (tree *BPlusTree) rebalance(n *node, i int) {
+	var (
+		child = n.children[i]
+		min   = tree.degree / 2
+	)
+	if len(child.keys) >= min {
+		return
+	}
+
+	if i > 0 && len(n.children[i-1].keys) > min {
+		tree.borrowLeft(n, i)
+		return
+	}
+	if i+1 < len(n.children) && len(n.children[i+1].keys) > min {
+		tree.borrowRight(n, i)
+		return
+	}
+	if i > 0 {
+		tree.merge(n, i-1)
+	} else if i+1 < len(n.children) {
+		tree.merge(n, i)
+	}
+}
+
+func (tree *BPlusTree) borrowLeft(n *node, i int) {
+	var (
+		left  = n.children[i-1]
+		child = n.children[i]
+		last  = len(left.keys) - 1
+	)
+	if child.leaf {
+		child.keys = insertAt(child.keys, 0, left.keys[last])
+		child.vals = insertAt(child.vals, 0, left.vals[last])
+		left.keys, left.vals = deleteAt(left.keys, last), deleteAt(left.vals, last)
+		n.keys[i-1] = child.keys[0]
+	} else {
+		var (
+			moved      = left.children[len(left.children)-1]
+			movedCount = left.counts[len(left.counts)-1]
+		)
+		child.keys = insertAt(child.keys, 0, n.keys[i-1])
+		child.children = insertNodeAt(child.children, 0, moved)
+		child.counts = insertCountAt(child.counts, 0, movedCount)
+		n.keys[i-1] = left.keys[last]
+		left.keys = deleteAt(left.keys, last)
+		left.children[len(left.children)-1] = nil
+		left.children = left.children[:len(left.children)-1]
+		left.counts = left.counts[:len(left.counts)-1]
+	}
+	n.counts[i-1], n.counts[i] = left.size(), child.size()
+}
+
+func (tree *BPlusTree) borrowRight(n *node, i int) {
+	var (
+		child = n.children[i]
+		right = n.children[i+1]
+	)
+	if child.leaf {
+		child.keys = append(child.keys, right.keys[0])
+		child.vals = append(child.vals, right.vals[0])
+		right.keys, right.vals = deleteAt(right.keys, 0), deleteAt(right.vals, 0)
+		n.keys[i] = right.keys[0]
+	} else {
+		child.keys = append(child.keys, n.keys[i])
+		child.children = append(child.children, right.children[0])
+		child.counts = append(child.counts, right.counts[0])
+		n.keys[i] = right.keys[0]
+		right.keys = deleteAt(right.keys, 0)
+		right.children = deleteNodeAt(right.children, 0)
+		right.counts = deleteCountAt(right.counts, 0)
+	}
+	n.counts[i], n.counts[i+1] = child.size(), right.size()
+}
+
+// merge folds children[i+1] of n into children[i].
+func (tree *BPlusTree) merge(n *node, i int) {
+	var (
+		left  = n.children[i]
+		right = n.children[i+1]
+	)
+	if left.leaf {
+		left.keys = append(left.keys, right.keys...)
+		left.vals = append(left.vals, right.vals...)
+		left.next = right.next
+	} else {
+		left.keys = append(append(left.keys, n.keys[i]), right.keys...)
+		left.children = append(left.children, right.children...)
+		left.counts = append(left.counts, right.counts...)
+	}
+	n.keys = deleteAt(n.keys, i)
+	n.children = deleteNodeAt(n.children, i+1)
+	n.counts = deleteCountAt(n.counts, i+1)
+	n.counts[i] = left.size()
+}
+
+func (n *node) size() uint64 {
+	if n.leaf {
+		return uint64(len(n.keys))
+	}
+	var size uint64
+	for _, c := range n.counts {
+		size += c
+	}
+	return size
+}
+
+func insertAt(s []interface{}, i int, v interface{}) []interface{} {
+	s = append(s, nil)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func deleteAt(s []interface{}, i int) []interface{} {
+	copy(s[i:], s[i+1:])
+	s[len(s)-1] = nil
+	return s[:len(s)-1]
+}
+
+func clearTail(s []interface{}, from int) {
+	for i := from; i < len(s); i++ {
+		s[i] = nil
+	}
+}
+
+func insertNodeAt(s []*node, i int, v *node) []*node {
+	s = append(s, nil)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func deleteNodeAt(s []*node, i int) []*node {
+	copy(s[i:], s[i+1:])
+	s[len(s)-1] = nil
+	return s[:len(s)-1]
+}
+
+func insertCountAt(s []uint64, i int, v uint64) []uint64 {
+	s = append(s, 0)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func deleteCountAt(s []uint64, i int) []uint64 {
+	copy(s[i:], s[i+1:])
+	return s[:len(s)-1]
+}
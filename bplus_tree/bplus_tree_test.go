@@ -0,0 +1,223 @@
+package bplus_tree
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/bostree"
+	"github.com/bostree/index_conformance"
+)
+
+func intCmp(k1, k2 interface{}) int {
+	return k1.(int) - k2.(int)
+}
+
+func float64Cmp(k1, k2 interface{}) int {
+	var (
+		f1 = k1.(float64)
+		f2 = k2.(float64)
+	)
+	if f1 > f2 {
+		return 1
+	}
+	if f1 == f2 {
+		return 0
+	}
+	return -1
+}
+
+// checkNode verifies the counts and separator bounds below n and returns the
+// number of entries there.
+func checkNode(t *testing.T, tree *BPlusTree, n *node, lo, hi interface{}) uint64 {
+	for _, key := range n.keys {
+		if (lo != nil && tree.CmpFunc(key, lo) < 0) || (hi != nil && tree.CmpFunc(key, hi) > 0) {
+			t.Fatalf("Expected %v within [%v, %v]\n", key, lo, hi)
+		}
+	}
+	if n.leaf {
+		return uint64(len(n.keys))
+	}
+	var total uint64
+	for i, child := range n.children {
+		var clo, chi = lo, hi
+		if i > 0 {
+			clo = n.keys[i-1]
+		}
+		if i < len(n.keys) {
+			chi = n.keys[i]
+		}
+		count := checkNode(t, tree, child, clo, chi)
+		if count != n.counts[i] {
+			t.Fatalf("Expected count %d, but got %d\n", count, n.counts[i])
+		}
+		total += count
+	}
+	return total
+}
+
+func TestBPlusTreeConformance(t *testing.T) {
+	for _, degree := range []int{4, DefaultDegree} {
+		index_conformance.Run(t, func(cmp func(k1, k2 interface{}) int) bostree.OrderedIndex {
+			return BuildWithDegree(cmp, degree)
+		})
+	}
+}
+
+func TestBPlusTreeAgainstSlice(t *testing.T) {
+	for _, degree := range []int{4, 5, 16, DefaultDegree} {
+		var (
+			tree = BuildWithDegree(intCmp, degree)
+			ref  []int
+			r    = rand.New(rand.NewSource(int64(degree)))
+		)
+		for op := 0; op < 20000; op++ {
+			key := r.Intn(500)
+			if r.Intn(3) > 0 {
+				tree.Insert(key, key*2)
+				i := sort.SearchInts(ref, key+1)
+				ref = append(ref, 0)
+				copy(ref[i+1:], ref[i:])
+				ref[i] = key
+			} else {
+				i := sort.SearchInts(ref, key)
+				found := i < len(ref) && ref[i] == key
+				if removed := tree.Remove(key); removed != found {
+					t.Fatalf("Degree %d: Expected Remove(%d) = %v, but got %v\n", degree, key, found, removed)
+				}
+				if found {
+					ref = append(ref[:i], ref[i+1:]...)
+				}
+			}
+		}
+
+		if total := checkNode(t, tree, tree.root, nil, nil); total != uint64(len(ref)) || tree.NodeCount() != total {
+			t.Fatalf("Degree %d: Expected %d entries, but got %d/%d\n", degree, len(ref), total, tree.NodeCount())
+		}
+		for i, key := range ref {
+			k, v, ok := tree.Select(uint64(i))
+			if !ok || k != key || v != key*2 {
+				t.Fatalf("Degree %d: Expected Select(%d) = %d, but got %v\n", degree, i, key, k)
+			}
+		}
+		for key := -1; key <= 501; key++ {
+			if rank := tree.Rank(key); rank != uint64(sort.SearchInts(ref, key)) {
+				t.Fatalf("Degree %d: Expected Rank(%d) = %d, but got %d\n", degree, key, sort.SearchInts(ref, key), rank)
+			}
+			i := sort.SearchInts(ref, key)
+			if _, ok := tree.LookUp(key); ok != (i < len(ref) && ref[i] == key) {
+				t.Fatalf("Degree %d: Expected LookUp(%d) = %v\n", degree, key, !ok)
+			}
+		}
+	}
+}
+
+func TestBPlusTreeLeafChain(t *testing.T) {
+	var tree = BuildWithDegree(intCmp, 4)
+	for _, key := range rand.New(rand.NewSource(1)).Perm(1000) {
+		tree.Insert(key, nil)
+	}
+	for key := 0; key < 1000; key += 2 {
+		tree.Remove(key)
+	}
+
+	var n = tree.root
+	for !n.leaf {
+		n = n.children[0]
+	}
+	var expected = 1
+	for ; n != nil; n = n.next {
+		for _, key := range n.keys {
+			if key != expected {
+				t.Fatalf("Expected %d, but got %v\n", expected, key)
+			}
+			expected += 2
+		}
+	}
+	if expected != 1001 {
+		t.Errorf("Expected to walk up to 999, but stopped at %d\n", expected-2)
+	}
+}
+
+// The benchmarks below mirror the README table for BOSTree and BPlusTree on
+// the same data.
+
+const benchSize = 1000000
+
+var (
+	benchAVL   *bostree.BOSTree
+	benchBPlus *BPlusTree
+)
+
+func benchTrees() (*bostree.BOSTree, *BPlusTree) {
+	if benchAVL == nil {
+		benchAVL = bostree.Build(float64Cmp)
+		benchBPlus = Build(float64Cmp)
+		for i := 0; i < benchSize; i++ {
+			val := fmt.Sprintf("p%d", i)
+			benchAVL.Insert(float64(i), val)
+			benchBPlus.Insert(float64(i), val)
+		}
+	}
+	return benchAVL, benchBPlus
+}
+
+func BenchmarkLookUp(b *testing.B) {
+	avl, bplus := benchTrees()
+	for _, key := range []float64{0, benchSize - 1, benchSize / 2} {
+		b.Run(fmt.Sprintf("AVL/%.0f", key), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				avl.LookUp(key)
+			}
+		})
+		b.Run(fmt.Sprintf("BPlus/%.0f", key), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bplus.LookUp(key)
+			}
+		})
+	}
+}
+
+func BenchmarkLookUpRandom(b *testing.B) {
+	avl, bplus := benchTrees()
+	b.Run("AVL", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			avl.LookUp(float64((i * 100003) % benchSize))
+		}
+	})
+	b.Run("BPlus", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bplus.LookUp(float64((i * 100003) % benchSize))
+		}
+	})
+}
+
+func BenchmarkRank(b *testing.B) {
+	avl, bplus := benchTrees()
+	var nodes = avl.BottomK(benchSize)
+	b.Run("AVL", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			avl.Rank(nodes[(i*100003)%benchSize])
+		}
+	})
+	b.Run("BPlus", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bplus.Rank(float64((i * 100003) % benchSize))
+		}
+	})
+}
+
+func BenchmarkSelect(b *testing.B) {
+	avl, bplus := benchTrees()
+	b.Run("AVL", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			avl.Select(uint64((i * 100003) % benchSize))
+		}
+	})
+	b.Run("BPlus", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bplus.Select(uint64((i * 100003) % benchSize))
+		}
+	})
+}
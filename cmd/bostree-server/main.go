@@ -0,0 +1,37 @@
+// Command bostree-server serves BOSTree-backed sorted sets over the Redis
+// protocol (RESP2/RESP3).
+//
+// Supported commands: ZADD, ZREM, ZCARD, ZSCORE, ZRANK, ZCOUNT, ZRANGE,
+// ZRANGEBYSCORE, plus PING, HELLO and QUIT.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	var addr = flag.String("addr", "127.0.0.1:6380", "address to listen on")
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("bostree-server listening on %s", ln.Addr())
+
+	var server = NewServer()
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		server.Close()
+	}()
+
+	if err := server.Serve(ln); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const maxBulkLen = 512 * 1024 * 1024
+
+var errProtocol = errors.New("Protocol error")
+
+// readCommand reads one request, either a RESP array of bulk strings or an
+// inline command line.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > 1024*1024 {
+		return nil, errProtocol
+	}
+	var args = make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errProtocol
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, errProtocol
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, errProtocol
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// replyWriter encodes replies in RESP2 or RESP3, depending on what the client
+// negotiated with HELLO.
+type replyWriter struct {
+	w     *bufio.Writer
+	proto int
+}
+
+func (rw *replyWriter) simple(s string) {
+	rw.w.WriteString("+" + s + "\r\n")
+}
+
+func (rw *replyWriter) err(msg string) {
+	rw.w.WriteString("-" + msg + "\r\n")
+}
+
+func (rw *replyWriter) int(n int64) {
+	rw.w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func (rw *replyWriter) bulk(s string) {
+	rw.w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+func (rw *replyWriter) null() {
+	if rw.proto >= 3 {
+		rw.w.WriteString("_\r\n")
+	} else {
+		rw.w.WriteString("$-1\r\n")
+	}
+}
+
+func (rw *replyWriter) array(n int) {
+	rw.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func (rw *replyWriter) mapHeader(n int) {
+	if rw.proto >= 3 {
+		rw.w.WriteString("%" + strconv.Itoa(n) + "\r\n")
+	} else {
+		rw.array(2 * n)
+	}
+}
+
+// double writes a RESP3 double, or a bulk string in RESP2.
+func (rw *replyWriter) double(f float64) {
+	if rw.proto >= 3 {
+		rw.w.WriteString("," + formatScore(f) + "\r\n")
+	} else {
+		rw.bulk(formatScore(f))
+	}
+}
+
+func formatScore(f float64) string {
+	if math.IsInf(f, 1) {
+		return "inf"
+	}
+	if math.IsInf(f, -1) {
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func parseScore(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, fmt.Errorf("value is not a valid float")
+	}
+	return f, nil
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bostree"
+)
+
+// Server serves the ZSET command family over RESP, keeping one sorted set per
+// key.
+type Server struct {
+	mu   sync.RWMutex
+	sets map[string]*bostree.SortedSet
+
+	connMu sync.Mutex
+	conns  map[net.Conn]struct{}
+	ln     net.Listener
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type command struct {
+	arity int // minimum number of arguments, including the command name
+	write bool
+	exec  func(s *Server, args []string) reply
+}
+
+// reply writes the result of a command. Commands build it while holding s.mu
+// and it runs after the lock is released, so a client that is slow to read
+// never holds up the others; it must not touch s.sets.
+type reply func(rw *replyWriter)
+
+func errReply(msg string) reply {
+	return func(rw *replyWriter) { rw.err(msg) }
+}
+
+func intReply(n int64) reply {
+	return func(rw *replyWriter) { rw.int(n) }
+}
+
+func nullReply(rw *replyWriter) {
+	rw.null()
+}
+
+var commands = map[string]command{
+	"ZADD":          {arity: 4, write: true, exec: (*Server).zadd},
+	"ZREM":          {arity: 3, write: true, exec: (*Server).zrem},
+	"ZCARD":         {arity: 2, exec: (*Server).zcard},
+	"ZSCORE":        {arity: 3, exec: (*Server).zscore},
+	"ZRANK":         {arity: 3, exec: (*Server).zrank},
+	"ZCOUNT":        {arity: 4, exec: (*Server).zcount},
+	"ZRANGE":        {arity: 4, exec: (*Server).zrange},
+	"ZRANGEBYSCORE": {arity: 4, exec: (*Server).zrangebyscore},
+}
+
+func NewServer() *Server {
+	return &Server{
+		sets:  make(map[string]*bostree.SortedSet),
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts connections on ln until Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	s.connMu.Lock()
+	s.ln = ln
+	s.connMu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.connMu.Lock()
+			closed := s.closed
+			s.connMu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.connMu.Lock()
+		if s.closed {
+			s.connMu.Unlock()
+			conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.connMu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+// Close stops the listener, drops all clients and waits for them to finish.
+func (s *Server) Close() error {
+	s.connMu.Lock()
+	s.closed = true
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connMu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.connMu.Lock()
+		delete(s.conns, conn)
+		s.connMu.Unlock()
+		s.wg.Done()
+	}()
+
+	var (
+		r  = bufio.NewReader(conn)
+		rw = &replyWriter{w: bufio.NewWriter(conn), proto: 2}
+	)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err == errProtocol {
+				rw.err("ERR " + err.Error())
+				rw.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if !s.dispatch(rw, args) {
+			rw.w.Flush()
+			return
+		}
+		// Replies to pipelined requests are batched until the input runs dry.
+		if r.Buffered() == 0 {
+			if err := rw.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// dispatch runs one command and reports whether the connection stays open.
+func (s *Server) dispatch(rw *replyWriter, args []string) bool {
+	var name = strings.ToUpper(args[0])
+	switch name {
+	case "PING":
+		if len(args) > 1 {
+			rw.bulk(args[1])
+		} else {
+			rw.simple("PONG")
+		}
+		return true
+	case "QUIT":
+		rw.simple("OK")
+		return false
+	case "HELLO":
+		s.hello(rw, args)
+		return true
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		rw.err("ERR unknown command '" + args[0] + "'")
+		return true
+	}
+	if len(args) < cmd.arity {
+		rw.err("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+		return true
+	}
+
+	var r reply
+	if cmd.write {
+		s.mu.Lock()
+		r = cmd.exec(s, args)
+		s.mu.Unlock()
+	} else {
+		s.mu.RLock()
+		r = cmd.exec(s, args)
+		s.mu.RUnlock()
+	}
+	r(rw)
+	return true
+}
+
+func (s *Server) hello(rw *replyWriter, args []string) {
+	if len(args) > 1 {
+		proto, err := strconv.Atoi(args[1])
+		if err != nil || proto < 2 || proto > 3 {
+			rw.err("NOPROTO unsupported protocol version")
+			return
+		}
+		rw.proto = proto
+	}
+	rw.mapHeader(3)
+	rw.bulk("server")
+	rw.bulk("bostree")
+	rw.bulk("proto")
+	rw.int(int64(rw.proto))
+	rw.bulk("mode")
+	rw.bulk("standalone")
+}
+
+func (s *Server) zadd(args []string) reply {
+	var (
+		flags bostree.ZAddFlag
+		i     = 2
+	)
+loop:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			flags |= bostree.ZAddNX
+		case "XX":
+			flags |= bostree.ZAddXX
+		case "GT":
+			flags |= bostree.ZAddGT
+		case "LT":
+			flags |= bostree.ZAddLT
+		case "CH":
+			flags |= bostree.ZAddCH
+		default:
+			break loop
+		}
+	}
+
+	var pairs = args[i:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return errReply("ERR syntax error")
+	}
+	var members = make([]bostree.ZMember, 0, len(pairs)/2)
+	for j := 0; j < len(pairs); j += 2 {
+		score, err := parseScore(pairs[j])
+		if err != nil {
+			return errReply("ERR " + err.Error())
+		}
+		members = append(members, bostree.ZMember{Member: pairs[j+1], Score: score})
+	}
+
+	zs, ok := s.sets[args[1]]
+	if !ok {
+		zs = bostree.NewSortedSet()
+	}
+	n, err := zs.ZAdd(flags, members...)
+	if err != nil {
+		return errReply("ERR " + err.Error())
+	}
+	if !ok && zs.ZCard() > 0 {
+		s.sets[args[1]] = zs
+	}
+	return intReply(int64(n))
+}
+
+func (s *Server) zrem(args []string) reply {
+	zs, ok := s.sets[args[1]]
+	if !ok {
+		return intReply(0)
+	}
+	n := zs.ZRem(args[2:]...)
+	if zs.ZCard() == 0 {
+		delete(s.sets, args[1])
+	}
+	return intReply(int64(n))
+}
+
+func (s *Server) zcard(args []string) reply {
+	if zs, ok := s.sets[args[1]]; ok {
+		return intReply(int64(zs.ZCard()))
+	}
+	return intReply(0)
+}
+
+func (s *Server) zscore(args []string) reply {
+	if zs, ok := s.sets[args[1]]; ok {
+		if score, ok := zs.ZScore(args[2]); ok {
+			return func(rw *replyWriter) { rw.double(score) }
+		}
+	}
+	return nullReply
+}
+
+func (s *Server) zrank(args []string) reply {
+	var withScore = false
+	if len(args) == 4 && strings.ToUpper(args[3]) == "WITHSCORE" {
+		withScore = true
+	} else if len(args) != 3 {
+		return errReply("ERR syntax error")
+	}
+
+	zs, ok := s.sets[args[1]]
+	if !ok {
+		return nullReply
+	}
+	rank, ok := zs.ZRank(args[2])
+	if !ok {
+		return nullReply
+	}
+	if withScore {
+		score, _ := zs.ZScore(args[2])
+		return func(rw *replyWriter) {
+			rw.array(2)
+			rw.int(int64(rank))
+			rw.double(score)
+		}
+	}
+	return intReply(int64(rank))
+}
+
+func (s *Server) zcount(args []string) reply {
+	min, max, err := parseScoreRange(args[2], args[3])
+	if err != nil {
+		return errReply("ERR " + err.Error())
+	}
+	if zs, ok := s.sets[args[1]]; ok {
+		return intReply(int64(zs.ZCount(min, max)))
+	}
+	return intReply(0)
+}
+
+// zrange implements ZRANGE key start stop [BYSCORE] [REV] [LIMIT offset count]
+// [WITHSCORES].
+func (s *Server) zrange(args []string) reply {
+	var (
+		byScore, rev, withScores bool
+		offset, count            int64 = 0, -1
+		hasLimit                 bool
+	)
+	for i := 4; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "BYSCORE":
+			byScore = true
+		case "REV":
+			rev = true
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			var err error
+			if offset, count, err = parseLimit(args[i+1:]); err != nil {
+				return errReply("ERR " + err.Error())
+			}
+			hasLimit = true
+			i += 2
+		default:
+			return errReply("ERR syntax error")
+		}
+	}
+	if hasLimit && !byScore {
+		return errReply("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	}
+
+	if byScore {
+		var lo, hi = args[2], args[3]
+		if rev {
+			lo, hi = hi, lo
+		}
+		min, max, err := parseScoreRange(lo, hi)
+		if err != nil {
+			return errReply("ERR " + err.Error())
+		}
+		var result []bostree.ZMember
+		if zs, ok := s.sets[args[1]]; ok {
+			if rev {
+				result = zs.ZRevRangeByScore(min, max, offset, count)
+			} else {
+				result = zs.ZRangeByScore(min, max, offset, count)
+			}
+		}
+		return membersReply(result, withScores)
+	}
+
+	start, err1 := strconv.ParseInt(args[2], 10, 64)
+	stop, err2 := strconv.ParseInt(args[3], 10, 64)
+	if err1 != nil || err2 != nil {
+		return errReply("ERR value is not an integer or out of range")
+	}
+	var result []bostree.ZMember
+	if zs, ok := s.sets[args[1]]; ok {
+		if rev {
+			result = zs.ZRevRange(start, stop)
+		} else {
+			result = zs.ZRange(start, stop)
+		}
+	}
+	return membersReply(result, withScores)
+}
+
+// zrangebyscore implements ZRANGEBYSCORE key min max [WITHSCORES]
+// [LIMIT offset count].
+func (s *Server) zrangebyscore(args []string) reply {
+	var (
+		withScores    bool
+		offset, count int64 = 0, -1
+	)
+	for i := 4; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			var err error
+			if offset, count, err = parseLimit(args[i+1:]); err != nil {
+				return errReply("ERR " + err.Error())
+			}
+			i += 2
+		default:
+			return errReply("ERR syntax error")
+		}
+	}
+
+	min, max, err := parseScoreRange(args[2], args[3])
+	if err != nil {
+		return errReply("ERR " + err.Error())
+	}
+	var result []bostree.ZMember
+	if zs, ok := s.sets[args[1]]; ok {
+		result = zs.ZRangeByScore(min, max, offset, count)
+	}
+	return membersReply(result, withScores)
+}
+
+func membersReply(members []bostree.ZMember, withScores bool) reply {
+	return func(rw *replyWriter) {
+		writeMembers(rw, members, withScores)
+	}
+}
+
+func writeMembers(rw *replyWriter, members []bostree.ZMember, withScores bool) {
+	if withScores && rw.proto >= 3 {
+		// RESP3 clients get [member, score] pairs.
+		rw.array(len(members))
+		for _, m := range members {
+			rw.array(2)
+			rw.bulk(m.Member)
+			rw.double(m.Score)
+		}
+		return
+	}
+	if withScores {
+		rw.array(2 * len(members))
+	} else {
+		rw.array(len(members))
+	}
+	for _, m := range members {
+		rw.bulk(m.Member)
+		if withScores {
+			rw.double(m.Score)
+		}
+	}
+}
+
+// parseLimit parses the "offset count" arguments following LIMIT.
+func parseLimit(args []string) (int64, int64, error) {
+	if len(args) < 2 {
+		return 0, 0, errors.New("syntax error")
+	}
+	offset, err1 := strconv.ParseInt(args[0], 10, 64)
+	count, err2 := strconv.ParseInt(args[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, errors.New("value is not an integer or out of range")
+	}
+	return offset, count, nil
+}
+
+func parseScoreRange(min, max string) (bostree.ScoreBound, bostree.ScoreBound, error) {
+	lo, err := parseScoreBound(min)
+	if err != nil {
+		return lo, lo, err
+	}
+	hi, err := parseScoreBound(max)
+	return lo, hi, err
+}
+
+// parseScoreBound parses "1.5", "(1.5", "-inf" and "+inf".
+func parseScoreBound(s string) (bostree.ScoreBound, error) {
+	var bound bostree.ScoreBound
+	if strings.HasPrefix(s, "(") {
+		bound.Exclusive = true
+		s = s[1:]
+	}
+	f, err := parseScore(s)
+	if err != nil {
+		return bound, errors.New("min or max is not a float")
+	}
+	bound.Value = f
+	return bound, nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	go server.Serve(ln)
+	return ln.Addr().String(), func() { server.Close() }
+}
+
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, addr string) *client {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &client{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func encode(args ...string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return sb.String()
+}
+
+func (c *client) do(t *testing.T, args ...string) interface{} {
+	if _, err := io.WriteString(c.conn, encode(args...)); err != nil {
+		t.Fatal(err)
+	}
+	return c.read(t)
+}
+
+// read decodes one reply. Errors come back as error values, doubles as
+// float64, nulls as nil, maps as flat slices.
+func (c *client) read(t *testing.T) interface{} {
+	line, err := readLine(c.r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch line[0] {
+	case '+':
+		return line[1:]
+	case '-':
+		return fmt.Errorf("%s", line[1:])
+	case ':':
+		n, _ := strconv.ParseInt(line[1:], 10, 64)
+		return n
+	case ',':
+		f, _ := strconv.ParseFloat(line[1:], 64)
+		return f
+	case '_':
+		return nil
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	case '*', '%':
+		n, _ := strconv.Atoi(line[1:])
+		if line[0] == '%' {
+			n *= 2
+		}
+		var items = make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			items = append(items, c.read(t))
+		}
+		return items
+	}
+	t.Fatalf("unexpected reply %q", line)
+	return nil
+}
+
+func TestServerCommands(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+	c := dial(t, addr)
+	defer c.conn.Close()
+
+	var cases = []struct {
+		args     []string
+		expected interface{}
+	}{
+		{[]string{"PING"}, "PONG"},
+		{[]string{"ZADD", "board", "1", "a", "2", "b", "3", "c"}, int64(3)},
+		{[]string{"ZADD", "board", "NX", "CH", "10", "a", "4", "d"}, int64(1)},
+		{[]string{"ZADD", "board", "XX", "CH", "2.5", "a"}, int64(1)},
+		{[]string{"ZCARD", "board"}, int64(4)},
+		{[]string{"ZCARD", "missing"}, int64(0)},
+		{[]string{"ZSCORE", "board", "a"}, "2.5"},
+		{[]string{"ZSCORE", "board", "zz"}, nil},
+		{[]string{"ZRANK", "board", "a"}, int64(1)},
+		{[]string{"ZRANK", "board", "zz"}, nil},
+		{[]string{"ZRANGE", "board", "0", "-1"}, []interface{}{"b", "a", "c", "d"}},
+		{[]string{"ZRANGE", "board", "0", "1", "REV", "WITHSCORES"}, []interface{}{"d", "4", "c", "3"}},
+		{[]string{"ZRANGE", "board", "(2", "+inf", "BYSCORE", "LIMIT", "1", "2"}, []interface{}{"c", "d"}},
+		{[]string{"ZRANGE", "board", "+inf", "3", "BYSCORE", "REV"}, []interface{}{"d", "c"}},
+		{[]string{"ZRANGE", "board", "+inf", "-inf", "BYSCORE", "REV", "LIMIT", "1", "2"}, []interface{}{"c", "a"}},
+		{[]string{"ZRANGEBYSCORE", "board", "-inf", "(3", "WITHSCORES"}, []interface{}{"b", "2", "a", "2.5"}},
+		{[]string{"ZCOUNT", "board", "2", "3"}, int64(3)},
+		{[]string{"ZREM", "board", "a", "zz"}, int64(1)},
+		{[]string{"ZCARD", "board"}, int64(3)},
+	}
+	for _, c2 := range cases {
+		if got := c.do(t, c2.args...); !reflect.DeepEqual(got, c2.expected) {
+			t.Errorf("%v: Expected %#v, but got %#v\n", c2.args, c2.expected, got)
+		}
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"ZADD", "board", "NX", "XX", "1", "a"},
+			{"ZADD", "board", "x", "a"},
+			{"ZADD", "board", "1"},
+			{"ZCOUNT", "board", "x", "1"},
+			{"ZCARD"},
+			{"NOPE"},
+		} {
+			if _, ok := c.do(t, args...).(error); !ok {
+				t.Errorf("%v: Expected an error reply\n", args)
+			}
+		}
+	})
+}
+
+func TestServerProtocolErrors(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+
+	for _, request := range []string{"*-1\r\n", "*x\r\n", "*1\r\n$-5\r\n"} {
+		c := dial(t, addr)
+		if _, err := io.WriteString(c.conn, request); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := c.read(t).(error); !ok {
+			t.Errorf("%q: Expected an error reply\n", request)
+		}
+		c.conn.Close()
+	}
+
+	// The server is still up.
+	c := dial(t, addr)
+	defer c.conn.Close()
+	if got := c.do(t, "PING"); got != "PONG" {
+		t.Errorf("Expected PONG, but got %#v\n", got)
+	}
+}
+
+func TestServerRESP3(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+	c := dial(t, addr)
+	defer c.conn.Close()
+
+	hello := c.do(t, "HELLO", "3").([]interface{})
+	if !reflect.DeepEqual(hello[2:4], []interface{}{"proto", int64(3)}) {
+		t.Errorf("Expected proto 3, but got %v\n", hello)
+	}
+	c.do(t, "ZADD", "k", "1.5", "a")
+	if got := c.do(t, "ZSCORE", "k", "a"); got != 1.5 {
+		t.Errorf("Expected double 1.5, but got %#v\n", got)
+	}
+	if got := c.do(t, "ZSCORE", "k", "b"); got != nil {
+		t.Errorf("Expected null, but got %#v\n", got)
+	}
+	got := c.do(t, "ZRANGE", "k", "0", "-1", "WITHSCORES")
+	if !reflect.DeepEqual(got, []interface{}{[]interface{}{"a", 1.5}}) {
+		t.Errorf("Expected [[a 1.5]], but got %#v\n", got)
+	}
+}
+
+func TestServerPipelining(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+	c := dial(t, addr)
+	defer c.conn.Close()
+
+	var batch strings.Builder
+	for i := 0; i < 100; i++ {
+		batch.WriteString(encode("ZADD", "p", strconv.Itoa(i), fmt.Sprintf("m%d", i)))
+	}
+	batch.WriteString(encode("ZCARD", "p"))
+	batch.WriteString("ZRANK p m42\r\n")
+	if _, err := io.WriteString(c.conn, batch.String()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if got := c.read(t); got != int64(1) {
+			t.Fatalf("Expected 1, but got %#v\n", got)
+		}
+	}
+	if got := c.read(t); got != int64(100) {
+		t.Errorf("Expected 100, but got %#v\n", got)
+	}
+	if got := c.read(t); got != int64(42) {
+		t.Errorf("Expected 42, but got %#v\n", got)
+	}
+}
+
+func TestServerSlowReader(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+
+	// A reply far larger than the socket buffers, which the client never
+	// reads.
+	var (
+		slow = dial(t, addr)
+		args = []string{"ZADD", "big"}
+		pad  = strings.Repeat("x", 100)
+	)
+	defer slow.conn.Close()
+	for i := 0; i < 200000; i++ {
+		args = append(args, strconv.Itoa(i), pad+strconv.Itoa(i))
+	}
+	slow.do(t, args...)
+	if _, err := io.WriteString(slow.conn, encode("ZRANGE", "big", "0", "-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes from other clients must not wait for it.
+	c := dial(t, addr)
+	defer c.conn.Close()
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	time.Sleep(100 * time.Millisecond)
+	if got := c.do(t, "ZADD", "big", "1", "new"); got != int64(1) {
+		t.Errorf("Expected 1, but got %#v\n", got)
+	}
+}
+
+func TestServerConcurrentClients(t *testing.T) {
+	addr, stop := startServer(t)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			c := dial(t, addr)
+			defer c.conn.Close()
+			for i := 0; i < 200; i++ {
+				c.do(t, "ZADD", "shared", strconv.Itoa(i), fmt.Sprintf("c%d-%d", n, i))
+				c.do(t, "ZRANGE", "shared", "0", "9")
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	c := dial(t, addr)
+	defer c.conn.Close()
+	if got := c.do(t, "ZCARD", "shared"); got != int64(1600) {
+		t.Errorf("Expected 1600, but got %#v\n", got)
+	}
+	if got := c.do(t, "ZCOUNT", "shared", "0", "0"); got != int64(8) {
+		t.Errorf("Expected 8, but got %#v\n", got)
+	}
+}
@@ -0,0 +1,83 @@
+package bostree
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// CmpError reports a comparator that broke its contract on the keys K1 and
+// K2, or panicked comparing them.
+type CmpError struct {
+	K1, K2 interface{}
+	Reason string
+}
+
+func (err *CmpError) Error() string {
+	return fmt.Sprintf("bostree: CmpFunc(%v, %v): %s", err.K1, err.K2, err.Reason)
+}
+
+// CmpErr returns the first comparator error a tree with CheckCmp found, or
+// nil.
+func (tree *BOSTree) CmpErr() error {
+	var err = (*CmpError)(atomic.LoadPointer(&tree.cmpErr))
+	if err == nil {
+		return nil
+	}
+	return err
+}
+
+// cmp compares k1 and k2 with the tree's CmpFunc, checked if CheckCmp is set.
+func (tree *BOSTree) cmp(k1, k2 interface{}) int {
+	if !tree.CheckCmp {
+		return tree.CmpFunc(k1, k2)
+	}
+	return tree.checkedCmp(k1, k2)
+}
+
+// checkedCmp compares k1 and k2 as well as k2 and k1, and k1 and k2 once more,
+// and records an error unless the answers agree. A panic counts as an error
+// and as equal keys, so the operation can go on without leaving the tree
+// half-updated.
+func (tree *BOSTree) checkedCmp(k1, k2 interface{}) int {
+	var (
+		cmp, err = tree.recoverCmp(k1, k2)
+		rev      int
+		again    int
+	)
+	if err == nil {
+		rev, err = tree.recoverCmp(k2, k1)
+	}
+	if err == nil {
+		again, err = tree.recoverCmp(k1, k2)
+	}
+	if err == nil && sign(rev) != -sign(cmp) {
+		err = &CmpError{k1, k2, fmt.Sprintf("not antisymmetric, got %d but %d with the keys swapped", cmp, rev)}
+	}
+	if err == nil && sign(again) != sign(cmp) {
+		err = &CmpError{k1, k2, fmt.Sprintf("not consistent, got %d and then %d", cmp, again)}
+	}
+	if err != nil {
+		atomic.CompareAndSwapPointer(&tree.cmpErr, nil, unsafe.Pointer(err))
+	}
+	return cmp
+}
+
+func (tree *BOSTree) recoverCmp(k1, k2 interface{}) (cmp int, err *CmpError) {
+	defer func() {
+		if r := recover(); r != nil {
+			cmp, err = 0, &CmpError{k1, k2, fmt.Sprintf("panic: %v", r)}
+		}
+	}()
+	return tree.CmpFunc(k1, k2), nil
+}
+
+func sign(cmp int) int {
+	if cmp < 0 {
+		return -1
+	}
+	if cmp > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,7 @@
+//go:build bostree_debug
+// +build bostree_debug
+
+package bostree
+
+// Built with -tags bostree_debug, every new tree checks its comparator.
+const checkCmpDefault = true
@@ -0,0 +1,6 @@
+//go:build !bostree_debug
+// +build !bostree_debug
+
+package bostree
+
+const checkCmpDefault = false
@@ -0,0 +1,94 @@
+package bostree
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckCmp(t *testing.T) {
+	var calls = 0
+	var cases = []struct {
+		name     string
+		cmp      func(k1, k2 interface{}) int
+		expected string
+	}{
+		{"Antisymmetry", func(k1, k2 interface{}) int {
+			return 1
+		}, "not antisymmetric"},
+		{"Consistency", func(k1, k2 interface{}) int {
+			// Every third call, which is the repeated one, flips.
+			calls++
+			if calls%3 == 0 {
+				return k2.(int) - k1.(int)
+			}
+			return k1.(int) - k2.(int)
+		}, "not consistent"},
+		{"Panic", func(k1, k2 interface{}) int {
+			if k1.(int) == 13 || k2.(int) == 13 {
+				panic("unlucky")
+			}
+			return k1.(int) - k2.(int)
+		}, "panic: unlucky"},
+	}
+	for _, c := range cases {
+		tree := Build(c.cmp)
+		tree.CheckCmp = true
+		for i := 0; i < 20; i++ {
+			tree.Insert(i, nil)
+		}
+		err := tree.CmpErr()
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: Expected an error containing %q, but got %v\n", c.name, c.expected, err)
+			continue
+		}
+		if cmpErr := err.(*CmpError); cmpErr.K1 == nil || cmpErr.K2 == nil {
+			t.Errorf("%s: Expected the error to name both keys, but got %v\n", c.name, err)
+		}
+		if tree.NodeCount() != 20 || actualCount(tree.RootNode) != 20 {
+			t.Errorf("%s: Expected the tree to stay intact with 20 nodes, but got %d\n", c.name, actualCount(tree.RootNode))
+		}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		tree := intTree()
+		tree.CheckCmp = true
+		for i := 0; i < 100; i++ {
+			tree.Insert(i%10, nil)
+		}
+		tree.LookUp(5)
+		tree.CountLessOrEqual(5)
+		if err := tree.CmpErr(); err != nil {
+			t.Errorf("Expected no error, but got %v\n", err)
+		}
+	})
+
+	t.Run("Concurrent Readers", func(t *testing.T) {
+		tree := Build(func(k1, k2 interface{}) int {
+			if k1.(int) == 13 || k2.(int) == 13 {
+				panic("unlucky")
+			}
+			return k1.(int) - k2.(int)
+		})
+		tree.CheckCmp = true
+		for i := 0; i < 10; i++ {
+			tree.Insert(i, nil)
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					tree.LookUp(13)
+					tree.CountLess(13)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := tree.CmpErr(); err == nil || !strings.Contains(err.Error(), "unlucky") {
+			t.Errorf("Expected a panic error, but got %v\n", err)
+		}
+	})
+}
@@ -0,0 +1,260 @@
+// Package comparators has ready-made CmpFuncs for BOSTree and the other
+// backends, plus combinators to build composite orders from them.
+//
+// Every comparator here is a total preorder: it is antisymmetric and
+// transitive for all inputs of its key type, NaNs included. Keys of another
+// type make the comparator panic.
+package comparators
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bostree/typed_tree"
+)
+
+// CmpFunc is the comparator type of BOSTree.CmpFunc.
+type CmpFunc = func(k1, k2 interface{}) int
+
+func Int(k1, k2 interface{}) int {
+	return typed_tree.CompareInt64(int64(k1.(int)), int64(k2.(int)))
+}
+
+func Int64(k1, k2 interface{}) int {
+	return typed_tree.CompareInt64(k1.(int64), k2.(int64))
+}
+
+func String(k1, k2 interface{}) int {
+	var (
+		s1 = k1.(string)
+		s2 = k2.(string)
+	)
+	if s1 < s2 {
+		return -1
+	}
+	if s1 > s2 {
+		return 1
+	}
+	return 0
+}
+
+// Float64Total orders float64 keys by the IEEE 754 total order:
+// -NaN < -Inf < ... < -0 < +0 < ... < +Inf < +NaN.
+func Float64Total(k1, k2 interface{}) int {
+	return typed_tree.CompareFloat64(k1.(float64), k2.(float64))
+}
+
+// Float64NaNFirst orders float64 keys numerically with all NaNs equal to each
+// other and before every number. -0 and +0 are equal.
+func Float64NaNFirst(k1, k2 interface{}) int {
+	return compareFloat64NaN(k1.(float64), k2.(float64), -1)
+}
+
+// Float64NaNLast is Float64NaNFirst with the NaNs after every number.
+func Float64NaNLast(k1, k2 interface{}) int {
+	return compareFloat64NaN(k1.(float64), k2.(float64), 1)
+}
+
+// compareFloat64NaN compares f1 and f2 with NaN placed at side (-1 or 1).
+func compareFloat64NaN(f1, f2 float64, side int) int {
+	var (
+		nan1 = math.IsNaN(f1)
+		nan2 = math.IsNaN(f2)
+	)
+	switch {
+	case nan1 && nan2:
+		return 0
+	case nan1:
+		return side
+	case nan2:
+		return -side
+	case f1 < f2:
+		return -1
+	case f1 > f2:
+		return 1
+	}
+	return 0
+}
+
+// Bytes orders []byte keys like bytes.Compare.
+func Bytes(k1, k2 interface{}) int {
+	return bytes.Compare(k1.([]byte), k2.([]byte))
+}
+
+// Time orders time.Time keys by instant, regardless of location.
+func Time(k1, k2 interface{}) int {
+	var (
+		t1 = k1.(time.Time)
+		t2 = k2.(time.Time)
+	)
+	if t1.Before(t2) {
+		return -1
+	}
+	if t1.After(t2) {
+		return 1
+	}
+	return 0
+}
+
+// BigInt orders *big.Int keys.
+func BigInt(k1, k2 interface{}) int {
+	return k1.(*big.Int).Cmp(k2.(*big.Int))
+}
+
+// BigFloat orders *big.Float keys. -0 and +0 are equal.
+func BigFloat(k1, k2 interface{}) int {
+	return k1.(*big.Float).Cmp(k2.(*big.Float))
+}
+
+// Reverse returns the opposite order of cmp.
+func Reverse(cmp CmpFunc) CmpFunc {
+	return func(k1, k2 interface{}) int {
+		return cmp(k2, k1)
+	}
+}
+
+// ThenBy orders by first, and keys that first finds equal by second.
+func ThenBy(first, second CmpFunc) CmpFunc {
+	return func(k1, k2 interface{}) int {
+		if cmp := first(k1, k2); cmp != 0 {
+			return cmp
+		}
+		return second(k1, k2)
+	}
+}
+
+// CaseFold orders string keys by their Unicode simple case folding, so "Go",
+// "GO" and "go" are equal.
+func CaseFold(k1, k2 interface{}) int {
+	var (
+		s1 = k1.(string)
+		s2 = k2.(string)
+	)
+	for s1 != "" && s2 != "" {
+		r1, n1 := utf8.DecodeRuneInString(s1)
+		r2, n2 := utf8.DecodeRuneInString(s2)
+		s1, s2 = s1[n1:], s2[n2:]
+		if r1 == r2 {
+			continue
+		}
+		if f1, f2 := foldRune(r1), foldRune(r2); f1 != f2 {
+			if f1 < f2 {
+				return -1
+			}
+			return 1
+		}
+	}
+	return typed_tree.CompareInt64(int64(len(s1)), int64(len(s2)))
+}
+
+// foldRune returns the smallest rune of r's case folding orbit.
+func foldRune(r rune) rune {
+	var min = r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// NaturalString orders string keys with runs of digits compared by their
+// numeric value, so "file2" < "file10". Numerically equal runs are ordered
+// by length, so "file1" < "file01", and only equal strings compare equal.
+func NaturalString(k1, k2 interface{}) int {
+	var (
+		s1 = k1.(string)
+		s2 = k2.(string)
+	)
+	for s1 != "" && s2 != "" {
+		var (
+			t1 = naturalToken(s1)
+			t2 = naturalToken(s2)
+		)
+		if cmp := compareNaturalTokens(t1, t2); cmp != 0 {
+			return cmp
+		}
+		s1, s2 = s1[len(t1):], s2[len(t2):]
+	}
+	return typed_tree.CompareInt64(int64(len(s1)), int64(len(s2)))
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// naturalToken returns the leading run of digits or non-digits of s.
+func naturalToken(s string) string {
+	var (
+		digit = isDigit(s[0])
+		i     = 1
+	)
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i]
+}
+
+// compareNaturalTokens compares two digit runs by value and then by length,
+// and any other pair as strings. A digit run and a non-digit run differ in
+// their first byte, so comparing them as strings keeps the order transitive.
+func compareNaturalTokens(t1, t2 string) int {
+	if !isDigit(t1[0]) || !isDigit(t2[0]) {
+		return String(t1, t2)
+	}
+	var (
+		v1 = trimLeadingZeros(t1)
+		v2 = trimLeadingZeros(t2)
+	)
+	if len(v1) != len(v2) {
+		return typed_tree.CompareInt64(int64(len(v1)), int64(len(v2)))
+	}
+	if cmp := String(v1, v2); cmp != 0 {
+		return cmp
+	}
+	return typed_tree.CompareInt64(int64(len(t1)), int64(len(t2)))
+}
+
+func trimLeadingZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
+
+// ByField orders struct keys, or pointers to structs, by the named exported
+// field using cmp. It panics if a key has no such field.
+func ByField(name string, cmp CmpFunc) CmpFunc {
+	// The field index is looked up once per struct type; the last one is
+	// cached, since a tree usually holds a single key type.
+	type fieldIndex struct {
+		typ   reflect.Type
+		index []int
+	}
+	var last atomic.Value
+	field := func(k interface{}) interface{} {
+		var (
+			v     = reflect.Indirect(reflect.ValueOf(k))
+			fi, _ = last.Load().(fieldIndex)
+		)
+		if fi.typ != v.Type() {
+			f, ok := v.Type().FieldByName(name)
+			if !ok {
+				panic(fmt.Sprintf("comparators: %s has no field %s", v.Type(), name))
+			}
+			fi = fieldIndex{v.Type(), f.Index}
+			last.Store(fi)
+		}
+		return v.FieldByIndex(fi.index).Interface()
+	}
+	return func(k1, k2 interface{}) int {
+		return cmp(field(k1), field(k2))
+	}
+}
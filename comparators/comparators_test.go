@@ -0,0 +1,132 @@
+package comparators
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// checkOrder checks that cmp is reflexive, antisymmetric and transitive over
+// every pair and triple of keys.
+func checkOrder(t *testing.T, name string, cmp CmpFunc, keys []interface{}) {
+	sign := func(i int) int {
+		if i < 0 {
+			return -1
+		}
+		if i > 0 {
+			return 1
+		}
+		return 0
+	}
+	for _, a := range keys {
+		if c := cmp(a, a); c != 0 {
+			t.Errorf("%s: Expected cmp(%v, %v) = 0, but got %d\n", name, a, a, c)
+		}
+		for _, b := range keys {
+			ab := sign(cmp(a, b))
+			if ba := sign(cmp(b, a)); ab != -ba {
+				t.Errorf("%s: Expected cmp(%v, %v) = %d to mirror cmp(%v, %v) = %d\n", name, a, b, ab, b, a, ba)
+			}
+			for _, c := range keys {
+				bc, ac := sign(cmp(b, c)), sign(cmp(a, c))
+				if ab <= 0 && bc <= 0 && ac > 0 {
+					t.Errorf("%s: Expected %v <= %v <= %v to give %v <= %v\n", name, a, b, c, a, c)
+				}
+				if ab == 0 && bc == 0 && ac != 0 {
+					t.Errorf("%s: Expected %v == %v == %v to give %v == %v\n", name, a, b, c, a, c)
+				}
+			}
+		}
+	}
+}
+
+// checkSorted checks that cmp orders keys as listed.
+func checkSorted(t *testing.T, name string, cmp CmpFunc, keys ...interface{}) {
+	for i := 1; i < len(keys); i++ {
+		if c := cmp(keys[i-1], keys[i]); c >= 0 {
+			t.Errorf("%s: Expected %v < %v, but got %d\n", name, keys[i-1], keys[i], c)
+		}
+	}
+}
+
+var (
+	negZero = math.Copysign(0, -1)
+	negNaN  = math.Float64frombits(math.Float64bits(math.NaN()) | 1<<63)
+	floats  = []interface{}{math.NaN(), negNaN, math.Inf(1), math.Inf(-1), 0.0, negZero, 1.5, -1.5, 2.0, math.MaxFloat64, math.SmallestNonzeroFloat64}
+)
+
+func TestFloat64(t *testing.T) {
+	checkOrder(t, "Float64Total", Float64Total, floats)
+	checkOrder(t, "Float64NaNFirst", Float64NaNFirst, floats)
+	checkOrder(t, "Float64NaNLast", Float64NaNLast, floats)
+
+	checkSorted(t, "Float64Total", Float64Total, negNaN, math.Inf(-1), -1.5, negZero, 0.0, math.SmallestNonzeroFloat64, math.Inf(1), math.NaN())
+	checkSorted(t, "Float64NaNFirst", Float64NaNFirst, math.NaN(), math.Inf(-1), 0.0, math.Inf(1))
+	checkSorted(t, "Float64NaNLast", Float64NaNLast, math.Inf(-1), 0.0, math.Inf(1), math.NaN())
+	if Float64NaNFirst(negNaN, math.NaN()) != 0 || Float64NaNLast(negZero, 0.0) != 0 {
+		t.Errorf("Expected NaNs and zeros to be equal\n")
+	}
+}
+
+func TestStrings(t *testing.T) {
+	var names = []interface{}{"", "a", "A", "file2", "file10", "file01", "file1", "file1a", "file1-", "x9y", "x10", "x 9", "Straße", "STRASSE", "ǅ", "ǆ", "Ǆ", "0", "00", "9", "abc"}
+	checkOrder(t, "NaturalString", NaturalString, names)
+	checkOrder(t, "CaseFold", CaseFold, names)
+	checkOrder(t, "Reverse(String)", Reverse(String), names)
+
+	checkSorted(t, "NaturalString", NaturalString, "file1", "file01", "file2", "file10", "file10a", "x9", "x10")
+	checkSorted(t, "CaseFold", CaseFold, "apple", "Banana", "cherry")
+	if CaseFold("Go", "gO") != 0 || CaseFold("ǅ", "ǆ") != 0 {
+		t.Errorf("Expected case variants to be equal\n")
+	}
+}
+
+func TestBytes(t *testing.T) {
+	checkOrder(t, "Bytes", Bytes, []interface{}{[]byte(nil), []byte{}, []byte{0}, []byte{1}, []byte{0, 1}, []byte{255}})
+	checkSorted(t, "Bytes", Bytes, []byte{}, []byte{0}, []byte{0, 1}, []byte{1})
+}
+
+func TestTime(t *testing.T) {
+	var (
+		now  = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		keys = []interface{}{now, now.In(time.FixedZone("X", 3600)), now.Add(time.Nanosecond), now.Add(-time.Hour), time.Time{}}
+	)
+	checkOrder(t, "Time", Time, keys)
+	checkSorted(t, "Time", Time, time.Time{}, now.Add(-time.Hour), now, now.Add(time.Nanosecond))
+	if Time(keys[0], keys[1]) != 0 {
+		t.Errorf("Expected the same instant in another zone to be equal\n")
+	}
+}
+
+func TestBig(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	checkOrder(t, "BigInt", BigInt, []interface{}{big.NewInt(-1), big.NewInt(0), big.NewInt(1), huge, new(big.Int).Neg(huge)})
+	checkSorted(t, "BigInt", BigInt, new(big.Int).Neg(huge), big.NewInt(0), huge)
+
+	var floats = []interface{}{big.NewFloat(-1), big.NewFloat(0), big.NewFloat(math.Copysign(0, -1)), big.NewFloat(math.Inf(1)), big.NewFloat(math.Inf(-1)), big.NewFloat(0.1)}
+	checkOrder(t, "BigFloat", BigFloat, floats)
+	checkSorted(t, "BigFloat", BigFloat, big.NewFloat(math.Inf(-1)), big.NewFloat(-1), big.NewFloat(0.1), big.NewFloat(math.Inf(1)))
+}
+
+func TestCombinators(t *testing.T) {
+	type player struct {
+		Region string
+		Score  int
+	}
+	var (
+		cmp  = ThenBy(ByField("Region", String), Reverse(ByField("Score", Int)))
+		keys = []interface{}{
+			player{"eu", 10}, player{"eu", 30}, &player{"us", 20}, player{"asia", 5}, player{"eu", 30}, &player{"asia", 50},
+		}
+	)
+	checkOrder(t, "ThenBy", cmp, keys)
+	checkSorted(t, "ThenBy", cmp, player{"asia", 50}, player{"asia", 5}, player{"eu", 30}, player{"eu", 10}, &player{"us", 20})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected ByField to panic on a missing field\n")
+		}
+	}()
+	ByField("Name", String)(player{}, player{})
+}
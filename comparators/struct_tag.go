@@ -0,0 +1,192 @@
+package comparators
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bostree/typed_tree"
+)
+
+// TagName is the struct tag read by BuildStruct.
+const TagName = "bostree"
+
+// tagField is one tagged field of a struct key.
+type tagField struct {
+	index    int
+	priority int
+	desc     bool
+	cmp      func(v1, v2 reflect.Value) int
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// BuildStruct returns a CmpFunc for keys of the struct type of sample, or of
+// pointers to it. Fields are compared in the order given by their tags:
+//
+//	type Key struct {
+//		Region string  `bostree:"1"`
+//		Score  float64 `bostree:"2,desc"`
+//		UserID int64   `bostree:"3"`
+//	}
+//
+// Untagged fields and fields tagged "-" are ignored. Supported field types
+// are bools, integers, floats (by total order), strings, []byte and
+// time.Time; anything else, a duplicate priority or a malformed tag is
+// reported here rather than when keys are compared.
+func BuildStruct(sample interface{}) (CmpFunc, error) {
+	var typ = reflect.TypeOf(sample)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("comparators: %v is not a struct", typ)
+	}
+
+	var (
+		fields     []tagField
+		priorities = map[int]string{}
+	)
+	for i := 0; i < typ.NumField(); i++ {
+		var (
+			sf       = typ.Field(i)
+			tag, ok  = sf.Tag.Lookup(TagName)
+			fieldErr = func(format string, args ...interface{}) error {
+				return fmt.Errorf("comparators: field %s of %v: %s", sf.Name, typ, fmt.Sprintf(format, args...))
+			}
+		)
+		if !ok || tag == "-" {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, fieldErr("unexported fields cannot be compared")
+		}
+
+		var (
+			parts         = strings.Split(tag, ",")
+			priority, err = strconv.Atoi(parts[0])
+			field         = tagField{index: i, priority: priority}
+		)
+		if err != nil || priority < 1 {
+			return nil, fieldErr("priority %q is not a positive integer", parts[0])
+		}
+		if other, ok := priorities[priority]; ok {
+			return nil, fieldErr("priority %d is taken by %s", priority, other)
+		}
+		priorities[priority] = sf.Name
+		for _, option := range parts[1:] {
+			switch option {
+			case "asc":
+				field.desc = false
+			case "desc":
+				field.desc = true
+			default:
+				return nil, fieldErr("unknown option %q", option)
+			}
+		}
+		if field.cmp = valueCmp(sf.Type); field.cmp == nil {
+			return nil, fieldErr("unsupported type %v", sf.Type)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("comparators: %v has no fields tagged %q", typ, TagName)
+	}
+	// Insertion sort by priority; structs have few fields.
+	for i := 1; i < len(fields); i++ {
+		for j := i; j > 0 && fields[j].priority < fields[j-1].priority; j-- {
+			fields[j], fields[j-1] = fields[j-1], fields[j]
+		}
+	}
+
+	return func(k1, k2 interface{}) int {
+		var (
+			v1 = structValue(k1, typ)
+			v2 = structValue(k2, typ)
+		)
+		for _, field := range fields {
+			cmp := field.cmp(v1.Field(field.index), v2.Field(field.index))
+			if cmp != 0 {
+				if field.desc {
+					return -cmp
+				}
+				return cmp
+			}
+		}
+		return 0
+	}, nil
+}
+
+// MustBuildStruct is BuildStruct for package-level comparators; it panics if
+// the struct type cannot be compared.
+func MustBuildStruct(sample interface{}) CmpFunc {
+	cmp, err := BuildStruct(sample)
+	if err != nil {
+		panic(err)
+	}
+	return cmp
+}
+
+// structValue dereferences k and checks that it holds typ.
+func structValue(k interface{}, typ reflect.Type) reflect.Value {
+	var v = reflect.Indirect(reflect.ValueOf(k))
+	if v.Type() != typ {
+		panic(fmt.Sprintf("comparators: comparator for %v got a %T key", typ, k))
+	}
+	return v
+}
+
+// valueCmp returns the comparison for values of typ, or nil.
+func valueCmp(typ reflect.Type) func(v1, v2 reflect.Value) int {
+	if typ == timeType {
+		return func(v1, v2 reflect.Value) int {
+			return Time(v1.Interface(), v2.Interface())
+		}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return func(v1, v2 reflect.Value) int {
+			return typed_tree.CompareInt64(boolInt(v1.Bool()), boolInt(v2.Bool()))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v1, v2 reflect.Value) int {
+			return typed_tree.CompareInt64(v1.Int(), v2.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(v1, v2 reflect.Value) int {
+			u1, u2 := v1.Uint(), v2.Uint()
+			if u1 < u2 {
+				return -1
+			}
+			if u1 > u2 {
+				return 1
+			}
+			return 0
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(v1, v2 reflect.Value) int {
+			return typed_tree.CompareFloat64(v1.Float(), v2.Float())
+		}
+	case reflect.String:
+		return func(v1, v2 reflect.Value) int {
+			return strings.Compare(v1.String(), v2.String())
+		}
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return func(v1, v2 reflect.Value) int {
+				return bytes.Compare(v1.Bytes(), v2.Bytes())
+			}
+		}
+	}
+	return nil
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package comparators
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type rankedKey struct {
+	Region  string    `bostree:"1"`
+	Score   float64   `bostree:"2,desc"`
+	UserID  int64     `bostree:"3"`
+	Joined  time.Time `bostree:"4,asc"`
+	Comment string
+}
+
+func TestBuildStruct(t *testing.T) {
+	cmp, err := BuildStruct(rankedKey{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v\n", err)
+	}
+	var (
+		day  = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		keys = []interface{}{
+			rankedKey{"eu", 90, 7, day, ""},
+			&rankedKey{"eu", 90, 7, day.Add(time.Hour), "b"},
+			rankedKey{"eu", 90, 8, day, "a"},
+			rankedKey{"eu", 50, 1, day, ""},
+			rankedKey{"us", 99, 2, day, ""},
+			rankedKey{"asia", 10, 3, day, ""},
+		}
+	)
+	checkOrder(t, "BuildStruct", cmp, keys)
+	checkSorted(t, "BuildStruct", cmp, keys[5], keys[0], keys[1], keys[2], keys[3], keys[4])
+	if cmp(rankedKey{Comment: "a"}, &rankedKey{Comment: "b"}) != 0 {
+		t.Errorf("Expected untagged fields to be ignored\n")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a key of another type\n")
+		}
+	}()
+	cmp(rankedKey{}, 1)
+}
+
+func TestBuildStructErrors(t *testing.T) {
+	var cases = []struct {
+		sample   interface{}
+		expected string
+	}{
+		{1, "int is not a struct"},
+		{struct{ A int }{}, "has no fields tagged"},
+		{struct {
+			A map[string]int `bostree:"1"`
+		}{}, "field A of struct { A map[string]int"},
+		{struct {
+			A int `bostree:"1"`
+			B int `bostree:"1"`
+		}{}, "priority 1 is taken by A"},
+		{struct {
+			A int `bostree:"0"`
+		}{}, "priority \"0\" is not a positive integer"},
+		{struct {
+			A int `bostree:"1,up"`
+		}{}, "unknown option \"up\""},
+		{struct {
+			a int `bostree:"1"`
+		}{}, "unexported"},
+	}
+	for _, c := range cases {
+		if _, err := BuildStruct(c.sample); err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%T: Expected an error containing %q, but got %v\n", c.sample, c.expected, err)
+		}
+	}
+}
+
+func BenchmarkBuildStruct(b *testing.B) {
+	var (
+		cmp = MustBuildStruct(rankedKey{})
+		k1  = rankedKey{"eu", 90, 7, time.Time{}, ""}
+		k2  = rankedKey{"eu", 90, 8, time.Time{}, ""}
+	)
+	for i := 0; i < b.N; i++ {
+		cmp(k1, k2)
+	}
+}
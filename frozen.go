@@ -0,0 +1,188 @@
+package bostree
+
+import (
+	"math/bits"
+
+	. "github.com/bostree/bos_node"
+)
+
+// FrozenLayout selects how a FrozenTree lays out keys for RankOfKey.
+type FrozenLayout int
+
+const (
+	// SortedLayout searches the sorted key array directly.
+	SortedLayout FrozenLayout = iota
+	// EytzingerLayout searches a breadth-first copy of the keys, which keeps
+	// the first steps of every search within a few cache lines.
+	EytzingerLayout
+)
+
+// FrozenTree is an immutable snapshot of a BOSTree in contiguous arrays.
+// Select is O(1) and RankOfKey is a binary search without early exits.
+type FrozenTree struct {
+	CmpFunc func(k1, k2 interface{}) int
+	Layout  FrozenLayout
+
+	// keys and vals hold the entries in order.
+	keys, vals []interface{}
+	// eytzinger holds the keys in breadth-first order from index 1 on, and
+	// eytzingerPos the position of each of them in keys.
+	eytzinger    []interface{}
+	eytzingerPos []uint32
+}
+
+// Freeze copies the tree into a FrozenTree with the given layout. The tree
+// itself is left as it is.
+func (tree *BOSTree) Freeze(layout FrozenLayout) *FrozenTree {
+	var (
+		n      = tree.NodeCount()
+		frozen = &FrozenTree{
+			CmpFunc: tree.CmpFunc,
+			Layout:  layout,
+			keys:    make([]interface{}, 0, n),
+			vals:    make([]interface{}, 0, n),
+		}
+	)
+	tree.Ascend(0, func(key, val interface{}) bool {
+		frozen.keys = append(frozen.keys, key)
+		frozen.vals = append(frozen.vals, val)
+		return true
+	})
+
+	if layout == EytzingerLayout {
+		frozen.eytzinger = make([]interface{}, n+1)
+		frozen.eytzingerPos = make([]uint32, n+1)
+		frozen.fillEytzinger(1, 0)
+	}
+	return frozen
+}
+
+// fillEytzinger lays out the subtree at Eytzinger index k, starting with the
+// sorted entry i, and returns the next sorted entry.
+func (frozen *FrozenTree) fillEytzinger(k, i int) int {
+	if k < len(frozen.eytzinger) {
+		i = frozen.fillEytzinger(2*k, i)
+		frozen.eytzinger[k] = frozen.keys[i]
+		frozen.eytzingerPos[k] = uint32(i)
+		i++
+		i = frozen.fillEytzinger(2*k+1, i)
+	}
+	return i
+}
+
+// Thaw builds a mutable, perfectly balanced BOSTree from the snapshot in O(n).
+func (frozen *FrozenTree) Thaw() *BOSTree {
+	var tree = Build(frozen.CmpFunc)
+	tree.RootNode = frozen.thaw(0, len(frozen.keys), nil)
+	return tree
+}
+
+func (frozen *FrozenTree) thaw(lo, hi int, parent *BOSNode) *BOSNode {
+	if lo >= hi {
+		return nil
+	}
+	var (
+		mid  = lo + (hi-lo)/2
+		node = NewNode()
+	)
+	node.Key = frozen.keys[mid]
+	node.Val = frozen.vals[mid]
+	node.ParentNode = parent
+	node.LeftChildNode = frozen.thaw(lo, mid, node)
+	node.RightChildNode = frozen.thaw(mid+1, hi, node)
+	node.Update()
+	return node
+}
+
+func (frozen *FrozenTree) Len() uint64 {
+	return uint64(len(frozen.keys))
+}
+
+func (frozen *FrozenTree) Select(index uint64) (key, val interface{}, ok bool) {
+	if index >= frozen.Len() {
+		return nil, nil, false
+	}
+	return frozen.keys[index], frozen.vals[index], true
+}
+
+// RankOfKey returns the number of entries whose key is less than key.
+func (frozen *FrozenTree) RankOfKey(key interface{}) uint64 {
+	if frozen.Layout == EytzingerLayout {
+		return frozen.rankEytzinger(key)
+	}
+	return frozen.rankSorted(key)
+}
+
+// rankSorted always takes ceil(log2 n) steps and only moves the base, which
+// the compiler can turn into a conditional move instead of a branch.
+func (frozen *FrozenTree) rankSorted(key interface{}) uint64 {
+	var (
+		keys = frozen.keys
+		base = 0
+		n    = len(keys)
+	)
+	if n == 0 {
+		return 0
+	}
+	for n > 1 {
+		half := n / 2
+		if frozen.CmpFunc(keys[base+half], key) < 0 {
+			base += half
+		}
+		n -= half
+	}
+	if frozen.CmpFunc(keys[base], key) < 0 {
+		base++
+	}
+	return uint64(base)
+}
+
+// rankEytzinger walks down the implicit tree to a leaf, then drops the right
+// turns taken after the last left turn to find the lower bound.
+func (frozen *FrozenTree) rankEytzinger(key interface{}) uint64 {
+	var (
+		keys = frozen.eytzinger
+		k    = 1
+	)
+	for k < len(keys) {
+		var right = 0
+		if frozen.CmpFunc(keys[k], key) < 0 {
+			right = 1
+		}
+		k = 2*k + right
+	}
+	k >>= uint(bits.TrailingZeros(^uint(k)) + 1)
+	if k == 0 {
+		return frozen.Len()
+	}
+	return uint64(frozen.eytzingerPos[k])
+}
+
+// LookUp returns the value of the first entry with an equal key.
+func (frozen *FrozenTree) LookUp(key interface{}) (interface{}, bool) {
+	var i = frozen.RankOfKey(key)
+	if i == frozen.Len() || frozen.CmpFunc(frozen.keys[i], key) != 0 {
+		return nil, false
+	}
+	return frozen.vals[i], true
+}
+
+// Quantile returns the entry at position floor(q*(n-1)), like
+// BOSTree.Quantile.
+func (frozen *FrozenTree) Quantile(q float64) (key, val interface{}, ok bool) {
+	index, ok := quantileIndex(q, frozen.Len())
+	if !ok {
+		return nil, nil, false
+	}
+	return frozen.Select(index)
+}
+
+// Ascend calls fn for the entries from position from onwards, in order, until
+// fn returns false.
+func (frozen *FrozenTree) Ascend(from uint64, fn func(key, val interface{}) bool) {
+	for i := from; i < frozen.Len(); i++ {
+		if !fn(frozen.keys[i], frozen.vals[i]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bostree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestFreezeThaw(t *testing.T) {
+	tree := intTree()
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 1000; i++ {
+		tree.Insert(r.Intn(300), i)
+	}
+
+	for _, layout := range []FrozenLayout{SortedLayout, EytzingerLayout} {
+		frozen := tree.Freeze(layout)
+		if frozen.Len() != tree.NodeCount() {
+			t.Fatalf("Layout %d: Expected %d, but got %d\n", layout, tree.NodeCount(), frozen.Len())
+		}
+		for i := uint64(0); i < tree.NodeCount(); i++ {
+			node := tree.Select(i)
+			key, val, ok := frozen.Select(i)
+			if !ok || key != node.Key || val != node.Val {
+				t.Fatalf("Layout %d: Expected Select(%d) = %v, but got %v\n", layout, i, node.Key, key)
+			}
+		}
+		if _, _, ok := frozen.Select(frozen.Len()); ok {
+			t.Errorf("Layout %d: Expected Select past the end to fail\n", layout)
+		}
+		for key := -1; key <= 301; key++ {
+			if rank, expected := frozen.RankOfKey(key), tree.RankOfKey(key); rank != expected {
+				t.Fatalf("Layout %d: Expected RankOfKey(%d) = %d, but got %d\n", layout, key, expected, rank)
+			}
+			val, ok := frozen.LookUp(key)
+			if node := tree.LowerBound(key); (node != nil && node.Key == key) != ok || (ok && node.Val != val) {
+				t.Fatalf("Layout %d: Expected LookUp(%d) to match the tree, but got %v/%v\n", layout, key, val, ok)
+			}
+		}
+		for _, q := range []float64{0, 0.25, 0.5, 0.99, 1} {
+			key, _, ok := frozen.Quantile(q)
+			if node := tree.Quantile(q); !ok || key != node.Key {
+				t.Errorf("Layout %d: Expected Quantile(%f) = %v, but got %v\n", layout, q, node.Key, key)
+			}
+		}
+
+		thawed := frozen.Thaw()
+		if thawed.NodeCount() != tree.NodeCount() || actualCount(thawed.RootNode) != tree.NodeCount() {
+			t.Fatalf("Layout %d: Expected %d nodes after Thaw, but got %d\n", layout, tree.NodeCount(), thawed.NodeCount())
+		}
+		var i uint64 = 0
+		thawed.Ascend(0, func(key, val interface{}) bool {
+			if node := tree.Select(i); key != node.Key || val != node.Val {
+				t.Fatalf("Layout %d: Expected %v at %d, but got %v\n", layout, node.Key, i, key)
+			}
+			i++
+			return true
+		})
+		for node := thawed.Select(0); node != nil; node = thawed.NxtNode(node) {
+			if balance := BOSTreeBalance(node); balance < -1 || balance > 1 {
+				t.Fatalf("Layout %d: Expected a balanced tree after Thaw, but got %d\n", layout, balance)
+			}
+			if uint64(node.Depth) != actualDepth(node) {
+				t.Fatalf("Layout %d: Expected depth %d, but got %d\n", layout, actualDepth(node), node.Depth)
+			}
+		}
+		thawed.Insert(150, -1)
+		if n := thawed.CountLessOrEqual(150); n != tree.CountLessOrEqual(150)+1 {
+			t.Errorf("Layout %d: Expected %d, but got %d\n", layout, tree.CountLessOrEqual(150)+1, n)
+		}
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		for _, layout := range []FrozenLayout{SortedLayout, EytzingerLayout} {
+			frozen := intTree().Freeze(layout)
+			if rank := frozen.RankOfKey(1); rank != 0 {
+				t.Errorf("Layout %d: Expected 0, but got %d\n", layout, rank)
+			}
+			if _, _, ok := frozen.Quantile(0.5); ok {
+				t.Errorf("Layout %d: Expected Quantile to fail on an empty tree\n", layout)
+			}
+			if frozen.Thaw().RootNode != nil {
+				t.Errorf("Layout %d: Expected an empty tree after Thaw\n", layout)
+			}
+		}
+	})
+}
+
+func BenchmarkRankOfKey(b *testing.B) {
+	const size = 1000000
+	tree := intTree()
+	for i := 0; i < size; i++ {
+		tree.Insert(i, nil)
+	}
+	b.Run("Tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree.RankOfKey((i * 100003) % size)
+		}
+	})
+	for layout, name := range map[FrozenLayout]string{SortedLayout: "Sorted", EytzingerLayout: "Eytzinger"} {
+		frozen := tree.Freeze(layout)
+		b.Run("Frozen/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				frozen.RankOfKey((i * 100003) % size)
+			}
+		})
+	}
+}
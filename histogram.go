@@ -0,0 +1,103 @@
+package bostree
+
+// Bucket is a histogram bucket of Count nodes with keys from Lo to Hi.
+type Bucket struct {
+	Lo, Hi interface{}
+	Count  uint64
+}
+
+// EquiDepthHistogram splits the nodes into buckets of nearly equal count:
+// bucket i holds the positions [i*n/buckets, (i+1)*n/buckets), and Lo and Hi
+// are the first and last keys there. A run of equal keys may span several
+// buckets. With fewer nodes than buckets, the empty buckets are left out.
+// It takes O(buckets log n).
+func (tree *BOSTree) EquiDepthHistogram(buckets int) []Bucket {
+	var (
+		n      = tree.NodeCount()
+		result []Bucket
+	)
+	for i := 0; i < buckets; i++ {
+		var (
+			from = uint64(i) * n / uint64(buckets)
+			to   = uint64(i+1) * n / uint64(buckets)
+		)
+		if from == to {
+			continue
+		}
+		result = append(result, Bucket{
+			Lo:    tree.Select(from).Key,
+			Hi:    tree.Select(to - 1).Key,
+			Count: to - from,
+		})
+	}
+	return result
+}
+
+// EquiWidthHistogram counts the float64 keys in buckets of equal width
+// between min and max. Bucket i holds the keys in [Lo, Hi); the last one
+// also holds max. Keys outside [min, max] are not counted. It takes
+// O(buckets log n).
+func (tree *BOSTree) EquiWidthHistogram(min, max float64, buckets int) []Bucket {
+	if buckets <= 0 || !(min <= max) {
+		return nil
+	}
+	var result = make([]Bucket, buckets)
+	for i := range result {
+		// Compute each boundary directly so the last one is exactly max.
+		var (
+			lo = min + (max-min)*float64(i)/float64(buckets)
+			hi = min + (max-min)*float64(i+1)/float64(buckets)
+		)
+		if i < buckets-1 {
+			result[i] = Bucket{Lo: lo, Hi: hi, Count: tree.CountRange(lo, hi)}
+		} else {
+			result[i] = Bucket{Lo: lo, Hi: max, Count: tree.CountLessOrEqual(max) - tree.CountLess(lo)}
+		}
+	}
+	return result
+}
+
+// ECDFPoint is a step of an empirical CDF: the share P of keys at or below
+// Key.
+type ECDFPoint struct {
+	Key interface{}
+	P   float64
+}
+
+// ECDF returns the empirical CDF of the keys, as an evaluator and as at most
+// points steps for plotting. The evaluator reads the tree on every call, in
+// O(log n), so it follows later changes; it returns 0 for an empty tree. The
+// steps are taken at evenly spaced positions and always include the first
+// and last key.
+func (tree *BOSTree) ECDF(points int) (func(key interface{}) float64, []ECDFPoint) {
+	var eval = func(key interface{}) float64 {
+		var n = tree.NodeCount()
+		if n == 0 {
+			return 0
+		}
+		return float64(tree.CountLessOrEqual(key)) / float64(n)
+	}
+
+	var (
+		n     = tree.NodeCount()
+		steps []ECDFPoint
+	)
+	if n == 0 || points <= 0 {
+		return eval, nil
+	}
+	if uint64(points) > n {
+		points = int(n)
+	}
+	for i := 0; i < points; i++ {
+		var index = n - 1
+		if points > 1 {
+			index = uint64(i) * (n - 1) / uint64(points-1)
+		}
+		var key = tree.Select(index).Key
+		if len(steps) > 0 && tree.cmp(steps[len(steps)-1].Key, key) == 0 {
+			continue
+		}
+		steps = append(steps, ECDFPoint{Key: key, P: eval(key)})
+	}
+	return eval, steps
+}
@@ -0,0 +1,106 @@
+package bostree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func floatTree(keys []float64) *BOSTree {
+	tree := Build(func(k1, k2 interface{}) int {
+		f1, f2 := k1.(float64), k2.(float64)
+		if f1 < f2 {
+			return -1
+		}
+		if f1 > f2 {
+			return 1
+		}
+		return 0
+	})
+	for _, key := range keys {
+		tree.Insert(key, nil)
+	}
+	return tree
+}
+
+func TestEquiDepthHistogram(t *testing.T) {
+	var keys []float64
+	for i := 0; i < 10; i++ {
+		keys = append(keys, float64(i/2))
+	}
+	tree := floatTree(keys)
+
+	var expected = []Bucket{{0.0, 1.0, 3}, {1.0, 2.0, 3}, {3.0, 4.0, 4}}
+	buckets := tree.EquiDepthHistogram(3)
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected %v, but got %v\n", expected, buckets)
+	}
+	for i := range expected {
+		if buckets[i] != expected[i] {
+			t.Errorf("Bucket %d: Expected %v, but got %v\n", i, expected[i], buckets[i])
+		}
+	}
+
+	if buckets := floatTree([]float64{1, 2}).EquiDepthHistogram(5); len(buckets) != 2 {
+		t.Errorf("Expected 2 buckets, but got %v\n", buckets)
+	}
+}
+
+func TestEquiWidthHistogram(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(1))
+		keys []float64
+	)
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, rng.Float64()*12-1)
+	}
+	keys = append(keys, 0, 10)
+	tree := floatTree(keys)
+
+	buckets := tree.EquiWidthHistogram(0, 10, 4)
+	for i, bucket := range buckets {
+		var (
+			lo, hi          = bucket.Lo.(float64), bucket.Hi.(float64)
+			expected uint64 = 0
+		)
+		for _, key := range keys {
+			if key >= lo && (key < hi || i == len(buckets)-1 && key == hi) {
+				expected++
+			}
+		}
+		if lo != 2.5*float64(i) || hi != 2.5*float64(i+1) || bucket.Count != expected {
+			t.Errorf("Bucket %d: Expected [%v, %v) with %d, but got %v\n", i, 2.5*float64(i), 2.5*float64(i+1), expected, bucket)
+		}
+	}
+	if buckets := tree.EquiWidthHistogram(1, 0, 4); buckets != nil {
+		t.Errorf("Expected nil, but got %v\n", buckets)
+	}
+}
+
+func TestECDF(t *testing.T) {
+	tree := floatTree([]float64{3, 1, 2, 2, 5, 5, 5, 9})
+	eval, steps := tree.ECDF(100)
+
+	for key, expected := range map[float64]float64{0: 0, 1: 0.125, 2: 0.375, 4.5: 0.5, 5: 0.875, 9: 1, 10: 1} {
+		if p := eval(key); p != expected {
+			t.Errorf("F(%v): Expected %v, but got %v\n", key, expected, p)
+		}
+	}
+	var expected = []ECDFPoint{{1.0, 0.125}, {2.0, 0.375}, {3.0, 0.5}, {5.0, 0.875}, {9.0, 1}}
+	if len(steps) != len(expected) {
+		t.Fatalf("Expected %v, but got %v\n", expected, steps)
+	}
+	for i := range expected {
+		if steps[i] != expected[i] {
+			t.Errorf("Step %d: Expected %v, but got %v\n", i, expected[i], steps[i])
+		}
+	}
+
+	// Downsampled to the first, middle and last positions.
+	if _, steps = tree.ECDF(3); len(steps) != 3 || steps[0].Key != 1.0 || steps[1].Key != 3.0 || steps[2].Key != 9.0 {
+		t.Errorf("Expected keys 1, 3, 9, but got %v\n", steps)
+	}
+	tree.Insert(0.0, nil)
+	if p := eval(0.0); p != 1.0/9 {
+		t.Errorf("Expected the evaluator to follow the tree, but got %v\n", p)
+	}
+}
@@ -0,0 +1,202 @@
+// Package index_conformance checks that a bostree.OrderedIndex backend
+// behaves like the reference: a sorted slice where equal keys keep their
+// insertion order.
+//
+// A backend's tests run the whole suite with
+//
+//	func TestConformance(t *testing.T) {
+//		index_conformance.Run(t, func(cmp func(k1, k2 interface{}) int) bostree.OrderedIndex {
+//			return Build(cmp)
+//		})
+//	}
+package index_conformance
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/bostree"
+)
+
+// Factory builds an empty backend ordered by cmp.
+type Factory func(cmp func(k1, k2 interface{}) int) bostree.OrderedIndex
+
+func intCmp(k1, k2 interface{}) int {
+	var (
+		i1 = k1.(int)
+		i2 = k2.(int)
+	)
+	if i1 < i2 {
+		return -1
+	}
+	if i1 > i2 {
+		return 1
+	}
+	return 0
+}
+
+type entry struct {
+	key, val int
+}
+
+// reference is the model every backend is compared with.
+type reference []entry
+
+func (ref *reference) insert(key, val int) {
+	var i = sort.Search(len(*ref), func(i int) bool { return (*ref)[i].key > key })
+	*ref = append(*ref, entry{})
+	copy((*ref)[i+1:], (*ref)[i:])
+	(*ref)[i] = entry{key, val}
+}
+
+func (ref reference) rank(key int) int {
+	return sort.Search(len(ref), func(i int) bool { return ref[i].key >= key })
+}
+
+func (ref *reference) remove(key int) bool {
+	var i = ref.rank(key)
+	if i == len(*ref) || (*ref)[i].key != key {
+		return false
+	}
+	*ref = append((*ref)[:i], (*ref)[i+1:]...)
+	return true
+}
+
+// Run runs the conformance suite against the backend built by newIndex.
+func Run(t *testing.T, newIndex Factory) {
+	t.Run("Empty", func(t *testing.T) {
+		idx := newIndex(intCmp)
+		if idx.Len() != 0 {
+			t.Errorf("Expected 0, but got %d\n", idx.Len())
+		}
+		if _, _, ok := idx.Select(0); ok {
+			t.Errorf("Expected Select(0) to fail on an empty index\n")
+		}
+		if _, ok := idx.LookUp(1); ok {
+			t.Errorf("Expected LookUp(1) to fail on an empty index\n")
+		}
+		if idx.Remove(1) {
+			t.Errorf("Expected Remove(1) to fail on an empty index\n")
+		}
+		if rank := idx.Rank(1); rank != 0 {
+			t.Errorf("Expected 0, but got %d\n", rank)
+		}
+		idx.Ascend(0, func(key, val interface{}) bool {
+			t.Errorf("Expected no entries, but got %v\n", key)
+			return true
+		})
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		idx := newIndex(intCmp)
+		for val, key := range []int{5, 3, 5, 1, 5} {
+			idx.Insert(key, val)
+		}
+		var vals []int
+		idx.Ascend(0, func(key, val interface{}) bool {
+			vals = append(vals, val.(int))
+			return true
+		})
+		if !equalInts(vals, []int{3, 1, 0, 2, 4}) {
+			t.Errorf("Expected insertion order among equal keys [3 1 0 2 4], but got %v\n", vals)
+		}
+		if val, ok := idx.LookUp(5); !ok || val != 0 {
+			t.Errorf("Expected the first inserted 5, but got %v\n", val)
+		}
+		idx.Remove(5)
+		if val, ok := idx.LookUp(5); !ok || val != 2 {
+			t.Errorf("Expected Remove to take the first 5, but got %v next\n", val)
+		}
+		if rank := idx.Rank(5); rank != 2 {
+			t.Errorf("Expected 2, but got %d\n", rank)
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		var (
+			idx = newIndex(intCmp)
+			ref reference
+			r   = rand.New(rand.NewSource(42))
+		)
+		for op := 0; op < 20000; op++ {
+			key := r.Intn(1000)
+			if r.Intn(3) > 0 {
+				idx.Insert(key, op)
+				ref.insert(key, op)
+			} else if removed, expected := idx.Remove(key), ref.remove(key); removed != expected {
+				t.Fatalf("Expected Remove(%d) = %v, but got %v\n", key, expected, removed)
+			}
+			if idx.Len() != uint64(len(ref)) {
+				t.Fatalf("Expected %d, but got %d\n", len(ref), idx.Len())
+			}
+		}
+		checkAgainst(t, idx, ref)
+	})
+
+	t.Run("Ascend", func(t *testing.T) {
+		idx := newIndex(intCmp)
+		for i := 0; i < 100; i++ {
+			idx.Insert(i, i)
+		}
+		var keys []int
+		idx.Ascend(90, func(key, val interface{}) bool {
+			keys = append(keys, key.(int))
+			return key.(int) < 95
+		})
+		if !equalInts(keys, []int{90, 91, 92, 93, 94, 95}) {
+			t.Errorf("Expected [90 91 92 93 94 95], but got %v\n", keys)
+		}
+		idx.Ascend(100, func(key, val interface{}) bool {
+			t.Errorf("Expected no entries past the end, but got %v\n", key)
+			return true
+		})
+	})
+}
+
+func checkAgainst(t *testing.T, idx bostree.OrderedIndex, ref reference) {
+	for i, e := range ref {
+		key, val, ok := idx.Select(uint64(i))
+		if !ok || key != e.key || val != e.val {
+			t.Fatalf("Expected Select(%d) = %v, but got %v/%v\n", i, e, key, val)
+		}
+	}
+	if _, _, ok := idx.Select(uint64(len(ref))); ok {
+		t.Fatalf("Expected Select(%d) to fail\n", len(ref))
+	}
+
+	for key := -1; key <= 1001; key++ {
+		i := ref.rank(key)
+		if rank := idx.Rank(key); rank != uint64(i) {
+			t.Fatalf("Expected Rank(%d) = %d, but got %d\n", key, i, rank)
+		}
+		val, ok := idx.LookUp(key)
+		if found := i < len(ref) && ref[i].key == key; ok != found || (found && val != ref[i].val) {
+			t.Fatalf("Expected LookUp(%d) = %v, but got %v/%v\n", key, found, val, ok)
+		}
+	}
+
+	var i = 0
+	idx.Ascend(0, func(key, val interface{}) bool {
+		if key != ref[i].key || val != ref[i].val {
+			t.Fatalf("Expected %v at %d, but got %v/%v\n", ref[i], i, key, val)
+		}
+		i++
+		return true
+	})
+	if i != len(ref) {
+		t.Fatalf("Expected Ascend to visit %d entries, but got %d\n", len(ref), i)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package bostree
+
+import (
+	. "github.com/bostree/bos_node"
+)
+
+// Interval is a closed interval [Start, End] with a value, as stored in an
+// IntervalTree.
+type Interval struct {
+	Start, End interface{}
+	Val        interface{}
+
+	node    *BOSNode
+	endNode *BOSNode
+	// maxEnd is the greatest End in the subtree of node.
+	maxEnd interface{}
+}
+
+// IntervalTree indexes intervals by start, with every node tracking the
+// greatest end below it, so overlap queries can skip subtrees that end too
+// early. A second tree keyed by end counts overlaps in O(log n).
+type IntervalTree struct {
+	// Tree holds the intervals ordered by start, as *Interval values. Its
+	// rank methods can be used directly, but it must not be changed.
+	Tree *BOSTree
+	ends *BOSTree
+}
+
+func NewIntervalTree(cmp_func func(k1, k2 interface{}) int) *IntervalTree {
+	var it = &IntervalTree{
+		Tree: Build(cmp_func),
+		ends: Build(cmp_func),
+	}
+	it.Tree.Augment = it.augment
+	return it
+}
+
+func (it *IntervalTree) augment(node *BOSNode) {
+	var iv = node.Val.(*Interval)
+	iv.maxEnd = iv.End
+	for _, child := range []*BOSNode{node.LeftChildNode, node.RightChildNode} {
+		if child != nil {
+			if end := child.Val.(*Interval).maxEnd; it.Tree.cmp(end, iv.maxEnd) > 0 {
+				iv.maxEnd = end
+			}
+		}
+	}
+}
+
+func (it *IntervalTree) Len() uint64 {
+	return it.Tree.NodeCount()
+}
+
+// Insert adds the interval [start, end] and returns it as a handle for Remove.
+// It returns nil, adding nothing, if end is before start.
+func (it *IntervalTree) Insert(start, end, val interface{}) *Interval {
+	if it.Tree.cmp(end, start) < 0 {
+		return nil
+	}
+	var iv = &Interval{Start: start, End: end, Val: val}
+	iv.endNode = it.ends.Insert(end, iv)
+	iv.node = it.Tree.Insert(start, iv)
+	return iv
+}
+
+func (it *IntervalTree) Remove(iv *Interval) {
+	it.Tree.Remove(iv.node)
+	it.ends.Remove(iv.endNode)
+}
+
+// IntervalOf returns the interval stored in a node of Tree.
+func IntervalOf(node *BOSNode) *Interval {
+	return node.Val.(*Interval)
+}
+
+// Overlapping returns the intervals that overlap [a, b], ordered by start,
+// in O(k log n) for k results. It returns nil if b is before a.
+func (it *IntervalTree) Overlapping(a, b interface{}) []*Interval {
+	if it.Tree.cmp(a, b) > 0 {
+		return nil
+	}
+	var result []*Interval
+	it.overlapping(it.Tree.RootNode, a, b, &result)
+	return result
+}
+
+func (it *IntervalTree) overlapping(node *BOSNode, a, b interface{}, result *[]*Interval) {
+	for node != nil {
+		var iv = node.Val.(*Interval)
+		if it.Tree.cmp(iv.maxEnd, a) < 0 {
+			// Everything below ends before a.
+			return
+		}
+		it.overlapping(node.LeftChildNode, a, b, result)
+		if it.Tree.cmp(iv.Start, b) > 0 {
+			// This and everything to the right starts after b.
+			return
+		}
+		if it.Tree.cmp(iv.End, a) >= 0 {
+			*result = append(*result, iv)
+		}
+		node = node.RightChildNode
+	}
+}
+
+// Stabbing returns the intervals that contain point, ordered by start.
+func (it *IntervalTree) Stabbing(point interface{}) []*Interval {
+	return it.Overlapping(point, point)
+}
+
+// CountOverlapping returns the number of intervals that overlap [a, b] in
+// O(log n): those starting at or before b, less those ending before a. Every
+// interval ending before a also starts before it, so for a <= b the second
+// count is part of the first. It returns 0 if b is before a.
+func (it *IntervalTree) CountOverlapping(a, b interface{}) uint64 {
+	if it.Tree.cmp(a, b) > 0 {
+		return 0
+	}
+	return it.Tree.CountLessOrEqual(b) - it.ends.CountLess(a)
+}
@@ -0,0 +1,112 @@
+package bostree
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/bostree/bos_node"
+)
+
+// checkMaxEnd checks the greatest end kept below node and returns it.
+func checkMaxEnd(t *testing.T, node *BOSNode) int {
+	if node == nil {
+		return -1
+	}
+	var expected = IntervalOf(node).End.(int)
+	for _, end := range []int{checkMaxEnd(t, node.LeftChildNode), checkMaxEnd(t, node.RightChildNode)} {
+		if end > expected {
+			expected = end
+		}
+	}
+	if maxEnd := IntervalOf(node).maxEnd.(int); maxEnd != expected {
+		t.Fatalf("Expected max end %d, but got %d\n", expected, maxEnd)
+	}
+	return expected
+}
+
+func TestIntervalTree(t *testing.T) {
+	var (
+		it        = NewIntervalTree(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+		intervals []*Interval
+		r         = rand.New(rand.NewSource(9))
+	)
+	for op := 0; op < 3000; op++ {
+		if len(intervals) == 0 || r.Intn(4) > 0 {
+			start := r.Intn(1000)
+			intervals = append(intervals, it.Insert(start, start+r.Intn(50), op))
+		} else {
+			i := r.Intn(len(intervals))
+			it.Remove(intervals[i])
+			intervals = append(intervals[:i], intervals[i+1:]...)
+		}
+	}
+	if it.Len() != uint64(len(intervals)) {
+		t.Fatalf("Expected %d, but got %d\n", len(intervals), it.Len())
+	}
+	checkMaxEnd(t, it.Tree.RootNode)
+
+	for q := 0; q < 500; q++ {
+		var (
+			a        = r.Intn(1100) - 50
+			b        = a + r.Intn(30)
+			expected = 0
+		)
+		for _, iv := range intervals {
+			if iv.Start.(int) <= b && iv.End.(int) >= a {
+				expected++
+			}
+		}
+		found := it.Overlapping(a, b)
+		if len(found) != expected {
+			t.Fatalf("[%d, %d]: Expected %d overlaps, but got %d\n", a, b, expected, len(found))
+		}
+		for i, iv := range found {
+			if iv.Start.(int) > b || iv.End.(int) < a {
+				t.Fatalf("[%d, %d]: Expected an overlap, but got [%v, %v]\n", a, b, iv.Start, iv.End)
+			}
+			if i > 0 && found[i-1].Start.(int) > iv.Start.(int) {
+				t.Fatalf("[%d, %d]: Expected results ordered by start\n", a, b)
+			}
+		}
+		if count := it.CountOverlapping(a, b); count != uint64(expected) {
+			t.Fatalf("[%d, %d]: Expected %d, but got %d\n", a, b, expected, count)
+		}
+	}
+
+	t.Run("Stabbing", func(t *testing.T) {
+		it := NewIntervalTree(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+		it.Insert(1, 5, "a")
+		it.Insert(3, 3, "b")
+		it.Insert(4, 10, "c")
+		it.Insert(6, 7, "d")
+		var vals []interface{}
+		for _, iv := range it.Stabbing(3) {
+			vals = append(vals, iv.Val)
+		}
+		if len(vals) != 2 || vals[0] != "a" || vals[1] != "b" {
+			t.Errorf("Expected [a b], but got %v\n", vals)
+		}
+		if count := it.CountOverlapping(5, 6); count != 3 {
+			t.Errorf("Expected 3, but got %d\n", count)
+		}
+	})
+
+	t.Run("Inverted", func(t *testing.T) {
+		it := NewIntervalTree(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+		it.Insert(6, 7, "a")
+		if count := it.CountOverlapping(10, 5); count != 0 {
+			t.Errorf("Expected 0 for an inverted query, but got %d\n", count)
+		}
+		if found := it.Overlapping(7, 6); found != nil {
+			t.Errorf("Expected nil for an inverted query, but got %v\n", found)
+		}
+
+		if iv := it.Insert(7, 3, "b"); iv != nil || it.Len() != 1 {
+			t.Errorf("Expected an inverted interval to be rejected\n")
+		}
+		it.Insert(1, 2, "c")
+		if count := it.CountOverlapping(5, 5); count != 0 {
+			t.Errorf("Expected 0, but got %d\n", count)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+// Package leaderboard keeps players ranked by score on top of a BOSTree.
+//
+// Higher scores rank first. Players with equal scores are ordered by the
+// submission that gave them their current score, earliest first.
+package leaderboard
+
+import (
+	"math"
+
+	"github.com/bostree"
+	. "github.com/bostree/bos_node"
+)
+
+// Mode decides how a new submission combines with a player's current score.
+type Mode int
+
+const (
+	// KeepBest keeps the highest score a player has submitted.
+	KeepBest Mode = iota
+	// KeepLatest replaces the score with the latest submission.
+	KeepLatest
+	// Accumulate adds every submission to the player's total.
+	Accumulate
+)
+
+// Entry is a player's position on the leaderboard. Rank is 0-based, 0 being
+// the top of the board.
+type Entry struct {
+	Player string
+	Score  float64
+	Rank   uint64
+}
+
+type entryKey struct {
+	score  float64
+	seq    uint64
+	player string
+}
+
+func entryCmp(k1, k2 interface{}) int {
+	var (
+		e1 = k1.(entryKey)
+		e2 = k2.(entryKey)
+	)
+	if e1.score > e2.score {
+		return -1
+	}
+	if e1.score < e2.score {
+		return 1
+	}
+	if e1.seq < e2.seq {
+		return -1
+	}
+	if e1.seq > e2.seq {
+		return 1
+	}
+	return 0
+}
+
+type Leaderboard struct {
+	mode  Mode
+	seq   uint64
+	tree  *bostree.BOSTree
+	index map[string]*BOSNode
+}
+
+func New(mode Mode) *Leaderboard {
+	return &Leaderboard{
+		mode:  mode,
+		tree:  bostree.Build(entryCmp),
+		index: make(map[string]*BOSNode),
+	}
+}
+
+func (lb *Leaderboard) Len() uint64 {
+	return lb.tree.NodeCount()
+}
+
+// Submit records a score for player and returns the player's resulting score
+// and whether their standing changed. NaN scores have no place on the board
+// and are ignored, as are Accumulate submissions whose total would be NaN.
+func (lb *Leaderboard) Submit(player string, score float64) (float64, bool) {
+	node, ok := lb.index[player]
+	if math.IsNaN(score) {
+		if ok {
+			return node.Key.(entryKey).score, false
+		}
+		return 0, false
+	}
+	if ok {
+		var cur = node.Key.(entryKey).score
+		switch lb.mode {
+		case KeepBest:
+			if score <= cur {
+				return cur, false
+			}
+		case KeepLatest:
+			if score == cur {
+				return cur, false
+			}
+		case Accumulate:
+			if score == 0 {
+				return cur, false
+			}
+			if math.IsNaN(score + cur) {
+				return cur, false
+			}
+			score += cur
+		}
+		lb.tree.Remove(node)
+	}
+
+	lb.seq++
+	lb.index[player] = lb.tree.Insert(entryKey{score: score, seq: lb.seq, player: player}, nil)
+	return score, true
+}
+
+// Remove takes player off the board.
+func (lb *Leaderboard) Remove(player string) bool {
+	node, ok := lb.index[player]
+	if !ok {
+		return false
+	}
+	lb.tree.Remove(node)
+	delete(lb.index, player)
+	return true
+}
+
+func (lb *Leaderboard) Score(player string) (float64, bool) {
+	node, ok := lb.index[player]
+	if !ok {
+		return 0, false
+	}
+	return node.Key.(entryKey).score, true
+}
+
+func (lb *Leaderboard) RankOf(player string) (uint64, bool) {
+	node, ok := lb.index[player]
+	if !ok {
+		return 0, false
+	}
+	return lb.tree.Rank(node), true
+}
+
+// Top returns the best n players.
+func (lb *Leaderboard) Top(n uint64) []Entry {
+	return lb.Page(0, n)
+}
+
+// Around returns player together with up to k players ranked directly above
+// and k players ranked directly below them.
+func (lb *Leaderboard) Around(player string, k uint64) []Entry {
+	rank, ok := lb.RankOf(player)
+	if !ok {
+		return nil
+	}
+	var lo, hi uint64 = 0, lb.tree.NodeCount() - 1
+	if rank > k {
+		lo = rank - k
+	}
+	if k < hi-rank {
+		hi = rank + k
+	}
+	return lb.Page(lo, hi-lo+1)
+}
+
+// Page returns up to limit players starting at rank offset.
+func (lb *Leaderboard) Page(offset, limit uint64) []Entry {
+	var n = lb.tree.NodeCount()
+	if offset >= n {
+		return nil
+	}
+	if limit > n-offset {
+		limit = n - offset
+	}
+
+	var (
+		result = make([]Entry, 0, limit)
+		node   = lb.tree.Select(offset)
+	)
+	for i := uint64(0); i < limit; i++ {
+		var key = node.Key.(entryKey)
+		result = append(result, Entry{Player: key.player, Score: key.score, Rank: offset + i})
+		node = lb.tree.NxtNode(node)
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package leaderboard
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func players(entries []Entry) []string {
+	var result []string
+	for _, e := range entries {
+		result = append(result, e.Player)
+	}
+	return result
+}
+
+func TestLeaderboardModes(t *testing.T) {
+	var cases = []struct {
+		mode     Mode
+		expected float64
+	}{
+		{KeepBest, 30},
+		{KeepLatest, 20},
+		{Accumulate, 60},
+	}
+	for _, c := range cases {
+		lb := New(c.mode)
+		lb.Submit("alice", 10)
+		lb.Submit("alice", 30)
+		lb.Submit("alice", 20)
+		if score, _ := lb.Score("alice"); score != c.expected {
+			t.Errorf("Mode %d: Expected %f, but got %f\n", c.mode, c.expected, score)
+		}
+		if lb.Len() != 1 {
+			t.Errorf("Mode %d: Expected 1 player, but got %d\n", c.mode, lb.Len())
+		}
+	}
+}
+
+func TestLeaderboardTies(t *testing.T) {
+	lb := New(KeepBest)
+	lb.Submit("late", 5)
+	lb.Submit("early", 10)
+	lb.Submit("later", 10)
+	lb.Submit("late", 10)
+
+	if got := players(lb.Top(3)); !reflect.DeepEqual(got, []string{"early", "later", "late"}) {
+		t.Errorf("Expected [early later late], but got %v\n", got)
+	}
+	if _, changed := lb.Submit("early", 10); changed {
+		t.Errorf("Expected an equal score not to change the standing\n")
+	}
+	if rank, _ := lb.RankOf("early"); rank != 0 {
+		t.Errorf("Expected 0, but got %d\n", rank)
+	}
+}
+
+func TestLeaderboardNaN(t *testing.T) {
+	lb := New(Accumulate)
+	for i, score := range []float64{1, 2, math.NaN(), 3, 0.5} {
+		lb.Submit(fmt.Sprintf("p%d", i), score)
+	}
+	if _, ok := lb.Score("p2"); ok || lb.Len() != 4 {
+		t.Errorf("Expected the NaN score to be ignored\n")
+	}
+	if got := players(lb.Top(4)); !reflect.DeepEqual(got, []string{"p3", "p1", "p0", "p4"}) {
+		t.Errorf("Expected [p3 p1 p0 p4], but got %v\n", got)
+	}
+
+	lb.Submit("inf", math.Inf(1))
+	if score, changed := lb.Submit("inf", math.Inf(-1)); changed || !math.IsInf(score, 1) {
+		t.Errorf("Expected a NaN total to be ignored, but got %f\n", score)
+	}
+	if score, changed := lb.Submit("p0", math.NaN()); changed || score != 1 {
+		t.Errorf("Expected a NaN submission to be ignored, but got %f\n", score)
+	}
+}
+
+func TestLeaderboardQueries(t *testing.T) {
+	lb := New(KeepLatest)
+	for i := 0; i < 100; i++ {
+		lb.Submit(fmt.Sprintf("p%02d", i), float64(i))
+	}
+
+	t.Run("RankOf", func(t *testing.T) {
+		if rank, ok := lb.RankOf("p99"); !ok || rank != 0 {
+			t.Errorf("Expected 0, but got %d\n", rank)
+		}
+		if rank, ok := lb.RankOf("p00"); !ok || rank != 99 {
+			t.Errorf("Expected 99, but got %d\n", rank)
+		}
+		if _, ok := lb.RankOf("nobody"); ok {
+			t.Errorf("Expected unknown player to have no rank\n")
+		}
+	})
+
+	t.Run("Top", func(t *testing.T) {
+		if got := players(lb.Top(3)); !reflect.DeepEqual(got, []string{"p99", "p98", "p97"}) {
+			t.Errorf("Expected [p99 p98 p97], but got %v\n", got)
+		}
+		if got := lb.Top(1000); len(got) != 100 {
+			t.Errorf("Expected 100, but got %d\n", len(got))
+		}
+	})
+
+	t.Run("Around", func(t *testing.T) {
+		got := lb.Around("p50", 2)
+		if !reflect.DeepEqual(players(got), []string{"p52", "p51", "p50", "p49", "p48"}) {
+			t.Errorf("Expected [p52 p51 p50 p49 p48], but got %v\n", players(got))
+		}
+		if got[2].Rank != 49 {
+			t.Errorf("Expected 49, but got %d\n", got[2].Rank)
+		}
+		if got := players(lb.Around("p98", 2)); !reflect.DeepEqual(got, []string{"p99", "p98", "p97", "p96"}) {
+			t.Errorf("Expected [p99 p98 p97 p96], but got %v\n", got)
+		}
+		if got := players(lb.Around("p00", 1)); !reflect.DeepEqual(got, []string{"p01", "p00"}) {
+			t.Errorf("Expected [p01 p00], but got %v\n", got)
+		}
+		if got := lb.Around("p50", math.MaxUint64); uint64(len(got)) != lb.Len() {
+			t.Errorf("Expected the whole board, but got %d entries\n", len(got))
+		}
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		if got := players(lb.Page(95, 10)); !reflect.DeepEqual(got, []string{"p04", "p03", "p02", "p01", "p00"}) {
+			t.Errorf("Expected [p04 p03 p02 p01 p00], but got %v\n", got)
+		}
+		if got := lb.Page(100, 10); len(got) != 0 {
+			t.Errorf("Expected empty page, but got %v\n", got)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		lb.Submit("p00", 1000)
+		lb.Remove("p99")
+		if got := players(lb.Top(2)); !reflect.DeepEqual(got, []string{"p00", "p98"}) {
+			t.Errorf("Expected [p00 p98], but got %v\n", got)
+		}
+	})
+}
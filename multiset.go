@@ -0,0 +1,159 @@
+package bostree
+
+import (
+	"math"
+
+	. "github.com/bostree/bos_node"
+)
+
+// multisetEntry is the Val of a Multiset node.
+type multisetEntry struct {
+	count uint64
+	// total is the sum of the counts in the node's subtree.
+	total uint64
+}
+
+// Multiset keeps one node per distinct key with its multiplicity, and the
+// total multiplicity of every subtree, so Select and RankOfKey treat a key
+// of count c as c repeated elements in O(log d) for d distinct keys.
+type Multiset struct {
+	// Tree holds the distinct keys with *multisetEntry values. It must only
+	// be changed through the Multiset. Its order-statistic methods, and
+	// everything built on them such as Quantile, Sample, EquiDepthHistogram
+	// and stats.FromTree, count every key once and ignore multiplicities;
+	// use the Multiset's own Select, Rank and RankOfKey for those.
+	Tree *BOSTree
+}
+
+func NewMultiset(cmp_func func(k1, k2 interface{}) int) *Multiset {
+	var ms = &Multiset{Tree: Build(cmp_func)}
+	ms.Tree.Augment = augmentMultiset
+	return ms
+}
+
+func augmentMultiset(node *BOSNode) {
+	var entry = node.Val.(*multisetEntry)
+	entry.total = entry.count + subtreeTotal(node.LeftChildNode) + subtreeTotal(node.RightChildNode)
+}
+
+func subtreeTotal(node *BOSNode) uint64 {
+	if node == nil {
+		return 0
+	}
+	return node.Val.(*multisetEntry).total
+}
+
+// Add adds n copies of key and returns its node, or nil if key is new and n
+// is 0. It returns false, changing nothing, if the multiset would hold more
+// than math.MaxUint64 elements; since a key's count is part of the total,
+// that also keeps every count from overflowing.
+func (ms *Multiset) Add(key interface{}, n uint64) (*BOSNode, bool) {
+	if ms.Len() > math.MaxUint64-n {
+		return nil, false
+	}
+	if node := ms.Tree.LookUp(key); node != nil {
+		ms.setCount(node, node.Val.(*multisetEntry).count+n)
+		return node, true
+	}
+	if n == 0 {
+		return nil, true
+	}
+	return ms.Tree.Insert(key, &multisetEntry{count: n, total: n}), true
+}
+
+// RemoveN removes up to n copies of key and returns how many were removed.
+// The key's node goes away with its last copy.
+func (ms *Multiset) RemoveN(key interface{}, n uint64) uint64 {
+	var node = ms.Tree.LookUp(key)
+	if node == nil {
+		return 0
+	}
+	var count = node.Val.(*multisetEntry).count
+	if n >= count {
+		ms.Tree.Remove(node)
+		return count
+	}
+	ms.setCount(node, count-n)
+	return n
+}
+
+// setCount changes the count of node and the totals above it in O(log d).
+func (ms *Multiset) setCount(node *BOSNode, count uint64) {
+	node.Val.(*multisetEntry).count = count
+	for ; node != nil; node = node.ParentNode {
+		augmentMultiset(node)
+	}
+}
+
+// Count returns the multiplicity of key.
+func (ms *Multiset) Count(key interface{}) uint64 {
+	if node := ms.Tree.LookUp(key); node != nil {
+		return node.Val.(*multisetEntry).count
+	}
+	return 0
+}
+
+// CountOf returns the multiplicity of a node of Tree.
+func (ms *Multiset) CountOf(node *BOSNode) uint64 {
+	return node.Val.(*multisetEntry).count
+}
+
+// Len returns the number of elements, counting multiplicities.
+func (ms *Multiset) Len() uint64 {
+	return subtreeTotal(ms.Tree.RootNode)
+}
+
+// DistinctLen returns the number of distinct keys.
+func (ms *Multiset) DistinctLen() uint64 {
+	return ms.Tree.NodeCount()
+}
+
+// Select returns the node of the element at position index, counting
+// multiplicities, or nil if index is not less than Len.
+func (ms *Multiset) Select(index uint64) *BOSNode {
+	var node = ms.Tree.RootNode
+	for node != nil {
+		if leftTotal := subtreeTotal(node.LeftChildNode); index < leftTotal {
+			node = node.LeftChildNode
+		} else {
+			index -= leftTotal
+			var count = ms.CountOf(node)
+			if index < count {
+				return node
+			}
+			index -= count
+			node = node.RightChildNode
+		}
+	}
+	return nil
+}
+
+// Rank returns the position of the first copy of the key of node, a node of
+// Tree, counting multiplicities.
+func (ms *Multiset) Rank(node *BOSNode) uint64 {
+	var count = subtreeTotal(node.LeftChildNode)
+	for ; node.ParentNode != nil; node = node.ParentNode {
+		if node == node.ParentNode.RightChildNode {
+			count += subtreeTotal(node.ParentNode.LeftChildNode) + ms.CountOf(node.ParentNode)
+		}
+	}
+	return count
+}
+
+// RankOfKey returns the number of elements less than key, which is the
+// position of its first copy.
+func (ms *Multiset) RankOfKey(key interface{}) uint64 {
+	var (
+		node  = ms.Tree.RootNode
+		count uint64
+	)
+	for node != nil {
+		if ms.Tree.cmp(node.Key, key) < 0 {
+			count += subtreeTotal(node.LeftChildNode) + ms.CountOf(node)
+			node = node.RightChildNode
+		} else {
+			node = node.LeftChildNode
+		}
+	}
+	return count
+}
@@ -0,0 +1,119 @@
+package bostree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	. "github.com/bostree/bos_node"
+)
+
+func checkTotals(t *testing.T, node *BOSNode) uint64 {
+	if node == nil {
+		return 0
+	}
+	var expected = node.Val.(*multisetEntry).count + checkTotals(t, node.LeftChildNode) + checkTotals(t, node.RightChildNode)
+	if total := subtreeTotal(node); total != expected {
+		t.Fatalf("Expected subtree total %d, but got %d\n", expected, total)
+	}
+	return expected
+}
+
+func TestMultiset(t *testing.T) {
+	var (
+		ms     = NewMultiset(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+		counts = map[int]uint64{}
+		r      = rand.New(rand.NewSource(5))
+	)
+	for op := 0; op < 5000; op++ {
+		key := r.Intn(300)
+		if r.Intn(3) > 0 {
+			n := uint64(r.Intn(1000))
+			if _, ok := ms.Add(key, n); !ok {
+				t.Fatalf("Expected to add %d copies of %d\n", n, key)
+			}
+			counts[key] += n
+		} else {
+			n := uint64(r.Intn(1500))
+			expected := n
+			if counts[key] < n {
+				expected = counts[key]
+			}
+			if removed := ms.RemoveN(key, n); removed != expected {
+				t.Fatalf("Expected to remove %d of %d, but removed %d\n", expected, key, removed)
+			}
+			counts[key] -= expected
+		}
+		if counts[key] == 0 {
+			delete(counts, key)
+		}
+	}
+	checkTotals(t, ms.Tree.RootNode)
+
+	var (
+		keys  []int
+		total uint64
+	)
+	for key, count := range counts {
+		keys = append(keys, key)
+		total += count
+	}
+	sort.Ints(keys)
+	if ms.Len() != total || ms.DistinctLen() != uint64(len(keys)) {
+		t.Fatalf("Expected %d elements and %d keys, but got %d and %d\n", total, len(keys), ms.Len(), ms.DistinctLen())
+	}
+
+	// Compare Select and RankOfKey with the elements laid out in order.
+	var index uint64
+	for _, key := range keys {
+		if count := ms.Count(key); count != counts[key] {
+			t.Fatalf("Expected Count(%d) = %d, but got %d\n", key, counts[key], count)
+		}
+		if rank := ms.RankOfKey(key); rank != index {
+			t.Fatalf("Expected RankOfKey(%d) = %d, but got %d\n", key, index, rank)
+		}
+		if rank := ms.Rank(ms.Tree.LookUp(key)); rank != index {
+			t.Fatalf("Expected Rank of %d = %d, but got %d\n", key, index, rank)
+		}
+		for _, i := range []uint64{index, index + counts[key] - 1} {
+			if node := ms.Select(i); node == nil || node.Key != key {
+				t.Fatalf("Expected Select(%d) to be %d, but got %v\n", i, key, node)
+			}
+		}
+		index += counts[key]
+	}
+	if node := ms.Select(total); node != nil {
+		t.Errorf("Expected nil past the end, but got %v\n", node.Key)
+	}
+	if count := ms.Count(-1); count != 0 {
+		t.Errorf("Expected 0, but got %d\n", count)
+	}
+	if rank := ms.RankOfKey(1000); rank != total {
+		t.Errorf("Expected %d, but got %d\n", total, rank)
+	}
+	if node, ok := ms.Add(-1, 0); node != nil || !ok || ms.DistinctLen() != uint64(len(keys)) {
+		t.Errorf("Expected adding no copies of a new key to do nothing\n")
+	}
+}
+
+func TestMultisetOverflow(t *testing.T) {
+	var cmp = func(k1, k2 interface{}) int { return k1.(int) - k2.(int) }
+
+	ms := NewMultiset(cmp)
+	if _, ok := ms.Add(1, math.MaxUint64); !ok {
+		t.Fatalf("Expected MaxUint64 copies to fit\n")
+	}
+	if node, ok := ms.Add(1, 2); ok || node != nil || ms.Count(1) != math.MaxUint64 {
+		t.Errorf("Expected the count to stay at MaxUint64, but got %d\n", ms.Count(1))
+	}
+
+	ms = NewMultiset(cmp)
+	ms.Add(1, 1<<63)
+	if _, ok := ms.Add(2, 1<<63); ok {
+		t.Errorf("Expected a total past MaxUint64 to be rejected\n")
+	}
+	if ms.Len() != 1<<63 || ms.DistinctLen() != 1 || ms.Select(1<<63) != nil {
+		t.Errorf("Expected one key with 1<<63 copies, but got %d elements and %d keys\n", ms.Len(), ms.DistinctLen())
+	}
+}
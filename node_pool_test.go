@@ -0,0 +1,89 @@
+package bostree
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+
+	. "github.com/bostree/bos_node"
+)
+
+func TestNodePool(t *testing.T) {
+	var (
+		pool = NewNodePool(16)
+		tree = BuildWithPool(func(k1, k2 interface{}) int {
+			return k1.(int) - k2.(int)
+		}, pool)
+		r = rand.New(rand.NewSource(3))
+	)
+
+	for i := 0; i < 5000; i++ {
+		tree.Insert(r.Intn(1000), i)
+		if i%3 == 0 {
+			tree.PopMin()
+		}
+	}
+
+	var prev = -1
+	for node := tree.Min(); node != nil; node = tree.NxtNode(node) {
+		if node.Key.(int) < prev {
+			t.Fatalf("Expected ascending keys, but got %d after %d\n", node.Key, prev)
+		}
+		prev = node.Key.(int)
+		if node.HasLeftChild() && actualCount(node.LeftChildNode) != node.LeftChildCount() {
+			t.Fatalf("Expected %d, but got %d\n", actualCount(node.LeftChildNode), node.LeftChildCount())
+		}
+		if actualDepth(node) != uint64(node.Depth) {
+			t.Fatalf("Expected %d, but got %d\n", actualDepth(node), node.Depth)
+		}
+	}
+	if tree.NodeCount() != 5000-1667 {
+		t.Errorf("Expected %d, but got %d\n", 5000-1667, tree.NodeCount())
+	}
+
+	t.Run("Reuse", func(t *testing.T) {
+		var pool = NewNodePool(4)
+		var n = pool.Get()
+		n.Key = 1
+		pool.Put(n)
+		if reused := pool.Get(); reused != n || reused.Key != nil || reused.ParentNode != nil {
+			t.Errorf("Expected a zeroed recycled node, but got %v\n", reused)
+		}
+	})
+}
+
+// benchmarkRollingWindow keeps a window of the latest keys, inserting one and
+// popping the oldest per iteration, and reports GC activity per operation.
+func benchmarkRollingWindow(b *testing.B, pool *NodePool) {
+	const window = 100000
+	var tree = BuildWithPool(func(k1, k2 interface{}) int {
+		return k1.(int) - k2.(int)
+	}, pool)
+	for i := 0; i < window; i++ {
+		tree.Insert(i, nil)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tree.Insert(window+i, nil)
+		tree.PopMin()
+	}
+
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC), "gcs")
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
+
+func BenchmarkRollingWindow(b *testing.B) {
+	benchmarkRollingWindow(b, nil)
+}
+
+func BenchmarkRollingWindowPooled(b *testing.B) {
+	benchmarkRollingWindow(b, NewNodePool(DefaultSlabSize))
+}
@@ -0,0 +1,72 @@
+package bostree
+
+// OrderedIndex is the key-based API shared by the order-statistic backends,
+// so callers can pick a backend per workload. Positions are 0-based, and
+// entries with equal keys keep their insertion order.
+type OrderedIndex interface {
+	// Insert adds an entry after all entries with an equal key.
+	Insert(key, val interface{})
+	// Remove removes the first entry with an equal key and reports whether
+	// there was one.
+	Remove(key interface{}) bool
+	// LookUp returns the value of the first entry with an equal key.
+	LookUp(key interface{}) (interface{}, bool)
+	// Select returns the entry at position index.
+	Select(index uint64) (key, val interface{}, ok bool)
+	// Rank returns the number of entries with a key less than key.
+	Rank(key interface{}) uint64
+	// Ascend calls fn for the entries from position from onwards, in order,
+	// until fn returns false.
+	Ascend(from uint64, fn func(key, val interface{}) bool)
+	Len() uint64
+}
+
+// AVLIndex adapts a BOSTree to OrderedIndex.
+type AVLIndex struct {
+	Tree *BOSTree
+}
+
+func NewAVLIndex(cmp_func func(k1, k2 interface{}) int) *AVLIndex {
+	return &AVLIndex{Tree: Build(cmp_func)}
+}
+
+func (idx *AVLIndex) Insert(key, val interface{}) {
+	idx.Tree.Insert(key, val)
+}
+
+func (idx *AVLIndex) Remove(key interface{}) bool {
+	var node = idx.Tree.LowerBound(key)
+	if node == nil || idx.Tree.cmp(node.Key, key) != 0 {
+		return false
+	}
+	idx.Tree.Remove(node)
+	return true
+}
+
+func (idx *AVLIndex) LookUp(key interface{}) (interface{}, bool) {
+	var node = idx.Tree.LowerBound(key)
+	if node == nil || idx.Tree.cmp(node.Key, key) != 0 {
+		return nil, false
+	}
+	return node.Val, true
+}
+
+func (idx *AVLIndex) Select(index uint64) (key, val interface{}, ok bool) {
+	var node = idx.Tree.Select(index)
+	if node == nil {
+		return nil, nil, false
+	}
+	return node.Key, node.Val, true
+}
+
+func (idx *AVLIndex) Rank(key interface{}) uint64 {
+	return idx.Tree.RankOfKey(key)
+}
+
+func (idx *AVLIndex) Ascend(from uint64, fn func(key, val interface{}) bool) {
+	idx.Tree.Ascend(from, fn)
+}
+
+func (idx *AVLIndex) Len() uint64 {
+	return idx.Tree.NodeCount()
+}
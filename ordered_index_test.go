@@ -0,0 +1,14 @@
+package bostree_test
+
+import (
+	"testing"
+
+	"github.com/bostree"
+	"github.com/bostree/index_conformance"
+)
+
+func TestAVLIndexConformance(t *testing.T) {
+	index_conformance.Run(t, func(cmp func(k1, k2 interface{}) int) bostree.OrderedIndex {
+		return bostree.NewAVLIndex(cmp)
+	})
+}
@@ -0,0 +1,66 @@
+package bostree
+
+import (
+	"fmt"
+)
+
+// The prefix queries below work on trees of string or []byte keys ordered
+// bytewise, such as by comparators.String or comparators.Bytes. The keys
+// starting with a prefix then form one run, from the prefix itself up to
+// the prefix's successor bound.
+
+// PrefixRange returns the positions [lo, hi) of the nodes whose key starts
+// with prefix, which must have the key type of the tree.
+func (tree *BOSTree) PrefixRange(prefix interface{}) (lo, hi uint64) {
+	lo = tree.CountLess(prefix)
+	if bound, ok := prefixSuccessor(prefix); ok {
+		hi = tree.CountLess(bound)
+	} else {
+		hi = tree.NodeCount()
+	}
+	return lo, hi
+}
+
+// CountPrefix returns the number of nodes whose key starts with prefix.
+func (tree *BOSTree) CountPrefix(prefix interface{}) uint64 {
+	lo, hi := tree.PrefixRange(prefix)
+	return hi - lo
+}
+
+// RankWithinPrefix returns the number of nodes whose key starts with prefix
+// and is less than key.
+func (tree *BOSTree) RankWithinPrefix(prefix, key interface{}) uint64 {
+	lo, hi := tree.PrefixRange(prefix)
+	if rank := tree.CountLess(key); rank > lo {
+		if rank > hi {
+			return hi - lo
+		}
+		return rank - lo
+	}
+	return 0
+}
+
+// prefixSuccessor returns the least key greater than every key starting with
+// prefix. There is none if prefix is empty or all 0xff bytes.
+func prefixSuccessor(prefix interface{}) (interface{}, bool) {
+	var b []byte
+	switch p := prefix.(type) {
+	case string:
+		b = []byte(p)
+	case []byte:
+		b = append([]byte(nil), p...)
+	default:
+		panic(fmt.Sprintf("bostree: prefix queries need string or []byte keys, got %T", prefix))
+	}
+
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] != 0xff {
+			b[i]++
+			if _, ok := prefix.(string); ok {
+				return string(b[:i+1]), true
+			}
+			return b[:i+1], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,73 @@
+package bostree
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestPrefixQueries(t *testing.T) {
+	tree := Build(func(k1, k2 interface{}) int {
+		return strings.Compare(k1.(string), k2.(string))
+	})
+	var keys = []string{
+		"/", "/api", "/api/", "/api/users", "/api/users/7", "/api/v2", "/apiary",
+		"/blog", "/blog/1", "/blog/1", "\xff", "\xff\xff", "\xff\xffa", "a\xff", "a\xff\x00", "b",
+	}
+	for _, i := range rand.New(rand.NewSource(2)).Perm(len(keys)) {
+		tree.Insert(keys[i], nil)
+	}
+
+	for _, prefix := range []string{"", "/", "/api", "/api/", "/api/users", "/blog/1", "/c", "\xff", "\xff\xff", "a\xff", "zzz"} {
+		var (
+			lo, hi   = tree.PrefixRange(prefix)
+			expected uint64
+		)
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				expected++
+			}
+		}
+		if count := tree.CountPrefix(prefix); count != expected || hi-lo != expected {
+			t.Errorf("Prefix %q: Expected %d, but got %d\n", prefix, expected, count)
+		}
+		for i := lo; i < hi; i++ {
+			if key := tree.Select(i).Key.(string); !strings.HasPrefix(key, prefix) {
+				t.Errorf("Prefix %q: Expected %q in range to start with it\n", prefix, key)
+			}
+		}
+	}
+
+	var cases = []struct {
+		prefix, key string
+		expected    uint64
+	}{
+		{"/api/", "/api/users/7", 2},
+		{"/api/", "/api/v", 3},
+		{"/api/", "/api0", 4},
+		{"/api/", "/", 0},
+		{"/blog", "/blog/1", 1},
+	}
+	for _, c := range cases {
+		if rank := tree.RankWithinPrefix(c.prefix, c.key); rank != c.expected {
+			t.Errorf("Prefix %q, key %q: Expected %d, but got %d\n", c.prefix, c.key, c.expected, rank)
+		}
+	}
+
+	t.Run("Bytes", func(t *testing.T) {
+		tree := Build(func(k1, k2 interface{}) int {
+			return bytes.Compare(k1.([]byte), k2.([]byte))
+		})
+		for _, key := range keys {
+			tree.Insert([]byte(key), nil)
+		}
+		var prefix = []byte("/api")
+		if count := tree.CountPrefix(prefix); count != 6 {
+			t.Errorf("Expected 6, but got %d\n", count)
+		}
+		if string(prefix) != "/api" {
+			t.Errorf("Expected the prefix to stay unchanged, but got %q\n", prefix)
+		}
+	})
+}
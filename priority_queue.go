@@ -0,0 +1,135 @@
+package bostree
+
+import (
+	. "github.com/bostree/bos_node"
+)
+
+// Min returns the node with the smallest key, or nil if the tree is empty.
+func (tree *BOSTree) Min() *BOSNode {
+	var node = tree.RootNode
+	if node == nil {
+		return nil
+	}
+	for node.HasLeftChild() {
+		node = node.LeftChildNode
+	}
+	return node
+}
+
+// Max returns the node with the largest key, or nil if the tree is empty.
+func (tree *BOSTree) Max() *BOSNode {
+	var node = tree.RootNode
+	if node == nil {
+		return nil
+	}
+	for node.HasRightChild() {
+		node = node.RightChildNode
+	}
+	return node
+}
+
+// PopMin removes the node with the smallest key and returns its key and
+// value. ok is false if the tree is empty.
+func (tree *BOSTree) PopMin() (key, val interface{}, ok bool) {
+	return tree.pop(tree.Min())
+}
+
+// PopMax removes the node with the largest key and returns its key and
+// value. ok is false if the tree is empty.
+func (tree *BOSTree) PopMax() (key, val interface{}, ok bool) {
+	return tree.pop(tree.Max())
+}
+
+// pop removes node, reading it first since Remove may recycle it.
+func (tree *BOSTree) pop(node *BOSNode) (interface{}, interface{}, bool) {
+	if node == nil {
+		return nil, nil, false
+	}
+	var key, val = node.Key, node.Val
+	tree.Remove(node)
+	return key, val, true
+}
+
+// TopK returns the k nodes with the largest keys, largest first, in
+// O(log n + k).
+func (tree *BOSTree) TopK(k uint64) []*BOSNode {
+	if n := tree.NodeCount(); k > n {
+		k = n
+	}
+	var result = make([]*BOSNode, 0, k)
+	for node := tree.Max(); node != nil && uint64(len(result)) < k; node = tree.PrevNode(node) {
+		result = append(result, node)
+	}
+	return result
+}
+
+// BottomK returns the k nodes with the smallest keys, smallest first, in
+// O(log n + k).
+func (tree *BOSTree) BottomK(k uint64) []*BOSNode {
+	if n := tree.NodeCount(); k > n {
+		k = n
+	}
+	var result = make([]*BOSNode, 0, k)
+	for node := tree.Min(); node != nil && uint64(len(result)) < k; node = tree.NxtNode(node) {
+		result = append(result, node)
+	}
+	return result
+}
+
+// HeapItem is what HeapAdapter pushes and pops.
+type HeapItem struct {
+	Key interface{}
+	Val interface{}
+}
+
+// HeapAdapter lets a BOSTree stand in for a min-heap through
+// container/heap.Interface. The tree is always sorted, so the heap invariant
+// holds without moving anything: Swap only remembers which node the
+// following Pop has to remove. heap.Push, heap.Pop, heap.Remove and
+// heap.Init work as usual; to change a key, heap.Remove the item and
+// heap.Push it again instead of calling heap.Fix.
+type HeapAdapter struct {
+	Tree    *BOSTree
+	pending *BOSNode
+}
+
+func NewHeapAdapter(tree *BOSTree) *HeapAdapter {
+	return &HeapAdapter{Tree: tree}
+}
+
+func (h *HeapAdapter) Len() int {
+	return int(h.Tree.NodeCount())
+}
+
+func (h *HeapAdapter) Less(i, j int) bool {
+	// Index order is key order, so a later index is never less.
+	if i >= j {
+		return false
+	}
+	return h.Tree.cmp(h.Tree.Select(uint64(i)).Key, h.Tree.Select(uint64(j)).Key) < 0
+}
+
+func (h *HeapAdapter) Swap(i, j int) {
+	var last = h.Len() - 1
+	if j == last && i != last {
+		h.pending = h.Tree.Select(uint64(i))
+	} else if i == last && j != last {
+		h.pending = h.Tree.Select(uint64(j))
+	}
+}
+
+func (h *HeapAdapter) Push(x interface{}) {
+	var item = x.(HeapItem)
+	h.Tree.Insert(item.Key, item.Val)
+}
+
+func (h *HeapAdapter) Pop() interface{} {
+	var node = h.pending
+	h.pending = nil
+	if node == nil {
+		node = h.Tree.Max()
+	}
+	var item = HeapItem{Key: node.Key, Val: node.Val}
+	h.Tree.Remove(node)
+	return item
+}
@@ -0,0 +1,76 @@
+package bostree
+
+import (
+	"container/heap"
+	"math/rand"
+	"testing"
+)
+
+func intTree() *BOSTree {
+	return Build(func(k1, k2 interface{}) int {
+		return k1.(int) - k2.(int)
+	})
+}
+
+func TestPriorityQueue(t *testing.T) {
+	tree := intTree()
+	for _, key := range rand.New(rand.NewSource(1)).Perm(100) {
+		tree.Insert(key, nil)
+	}
+
+	t.Run("Min & Max", func(t *testing.T) {
+		if tree.Min().Key != 0 || tree.Max().Key != 99 {
+			t.Errorf("Expected 0/99, but got %v/%v\n", tree.Min().Key, tree.Max().Key)
+		}
+		if _, _, ok := intTree().PopMax(); ok || intTree().Min() != nil {
+			t.Errorf("Expected nil on an empty tree\n")
+		}
+	})
+
+	t.Run("TopK & BottomK", func(t *testing.T) {
+		top := tree.TopK(3)
+		if len(top) != 3 || top[0].Key != 99 || top[2].Key != 97 {
+			t.Errorf("Expected [99 98 97], but got %v\n", top)
+		}
+		bottom := tree.BottomK(200)
+		if len(bottom) != 100 || bottom[0].Key != 0 || bottom[99].Key != 99 {
+			t.Errorf("Expected all 100 nodes in order, but got %d\n", len(bottom))
+		}
+	})
+
+	t.Run("Pop", func(t *testing.T) {
+		if key, _, ok := tree.PopMin(); !ok || key != 0 || tree.Min().Key != 1 {
+			t.Errorf("Expected to pop 0, but got %v\n", key)
+		}
+		if key, _, ok := tree.PopMax(); !ok || key != 99 || tree.Max().Key != 98 {
+			t.Errorf("Expected to pop 99, but got %v\n", key)
+		}
+		if tree.NodeCount() != 98 {
+			t.Errorf("Expected 98, but got %d\n", tree.NodeCount())
+		}
+	})
+}
+
+func TestHeapAdapter(t *testing.T) {
+	h := NewHeapAdapter(intTree())
+	heap.Init(h)
+	for _, key := range rand.New(rand.NewSource(2)).Perm(50) {
+		heap.Push(h, HeapItem{Key: key, Val: key * 10})
+	}
+
+	if item := heap.Remove(h, 10).(HeapItem); item.Key != 10 {
+		t.Errorf("Expected to remove 10, but got %v\n", item.Key)
+	}
+	for expected := 0; expected < 50; expected++ {
+		if expected == 10 {
+			continue
+		}
+		item := heap.Pop(h).(HeapItem)
+		if item.Key != expected || item.Val != expected*10 {
+			t.Fatalf("Expected %d, but got %v\n", expected, item)
+		}
+	}
+	if h.Len() != 0 {
+		t.Errorf("Expected an empty heap, but got %d\n", h.Len())
+	}
+}
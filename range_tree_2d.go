@@ -0,0 +1,319 @@
+package bostree
+
+import (
+	"math"
+)
+
+// Point2D is a point stored in a RangeTree2D.
+type Point2D struct {
+	X, Y interface{}
+	Val  interface{}
+
+	// id breaks ties between equal coordinates, so every point has its own
+	// place in both orders.
+	id   uint64
+	node *rangeNode
+	dead bool
+}
+
+// rangeNode is a node of the outer tree, ordered by X. ys holds the points of
+// its subtree that are still alive, ordered by Y.
+type rangeNode struct {
+	point               *Point2D
+	parent, left, right *rangeNode
+	// size counts the nodes of the subtree, removed points included.
+	size int
+	ys   *BOSTree
+}
+
+// rangeTreeAlpha is the weight balance of the outer tree: a subtree whose
+// child holds more than this share of its nodes is rebuilt.
+const rangeTreeAlpha = 0.7
+
+// RangeTree2D answers orthogonal range queries over points. The outer tree
+// is ordered by X and kept balanced by rebuilding subtrees, scapegoat style,
+// since rotations would invalidate the secondary trees. Every outer node has
+// a BOSTree of the Ys below it, so a rectangle count is O(log n) rank queries,
+// O(log^2 n) in total. Inserts and removes take O(log^2 n) amortized.
+type RangeTree2D struct {
+	XCmp, YCmp func(k1, k2 interface{}) int
+
+	root *rangeNode
+	// size and dead count the outer nodes and the removed points among them.
+	size, dead int
+	lastID     uint64
+}
+
+func NewRangeTree2D(xCmp, yCmp func(k1, k2 interface{}) int) *RangeTree2D {
+	return &RangeTree2D{XCmp: xCmp, YCmp: yCmp}
+}
+
+// cmpX orders points by X, then by insertion.
+func (rt *RangeTree2D) cmpX(p1, p2 *Point2D) int {
+	if cmp := rt.XCmp(p1.X, p2.X); cmp != 0 {
+		return cmp
+	}
+	return compareIDs(p1.id, p2.id)
+}
+
+// cmpY orders points by Y, then by insertion.
+func (rt *RangeTree2D) cmpY(k1, k2 interface{}) int {
+	var (
+		p1 = k1.(*Point2D)
+		p2 = k2.(*Point2D)
+	)
+	if cmp := rt.YCmp(p1.Y, p2.Y); cmp != 0 {
+		return cmp
+	}
+	return compareIDs(p1.id, p2.id)
+}
+
+func compareIDs(id1, id2 uint64) int {
+	if id1 < id2 {
+		return -1
+	}
+	if id1 > id2 {
+		return 1
+	}
+	return 0
+}
+
+func (rt *RangeTree2D) Len() uint64 {
+	if rt.root == nil {
+		return 0
+	}
+	return rt.root.ys.NodeCount()
+}
+
+// Insert adds a point and returns it as a handle for Remove.
+func (rt *RangeTree2D) Insert(x, y, val interface{}) *Point2D {
+	rt.lastID++
+	var p = &Point2D{X: x, Y: y, Val: val, id: rt.lastID}
+	rt.size++
+	if rt.root == nil {
+		rt.root = rt.newNode(p, nil)
+		return p
+	}
+
+	var (
+		node  = rt.root
+		depth = 1
+	)
+	for {
+		node.ys.Insert(p, p)
+		node.size++
+		var next = &node.right
+		if rt.cmpX(p, node.point) < 0 {
+			next = &node.left
+		}
+		if *next == nil {
+			*next = rt.newNode(p, node)
+			break
+		}
+		node = *next
+		depth++
+	}
+
+	if float64(depth) > math.Log(float64(rt.size))/math.Log(1/rangeTreeAlpha) {
+		for node = p.node.parent; node != nil; node = node.parent {
+			if float64(maxInt(sizeOfRange(node.left), sizeOfRange(node.right))) > rangeTreeAlpha*float64(node.size) {
+				rt.rebuild(node)
+				break
+			}
+		}
+	}
+	return p
+}
+
+// Remove removes the point p, which must have come from Insert.
+func (rt *RangeTree2D) Remove(p *Point2D) {
+	if p.dead {
+		return
+	}
+	for node := p.node; node != nil; node = node.parent {
+		node.ys.Remove(node.ys.LowerBound(p))
+	}
+	p.dead = true
+	rt.dead++
+	if rt.dead > rt.size/2 {
+		rt.rebuild(rt.root)
+	}
+}
+
+func (rt *RangeTree2D) newNode(p *Point2D, parent *rangeNode) *rangeNode {
+	var node = &rangeNode{point: p, parent: parent, size: 1, ys: Build(rt.cmpY)}
+	node.ys.Insert(p, p)
+	p.node = node
+	return node
+}
+
+func sizeOfRange(node *rangeNode) int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// rebuild replaces the subtree at node with a perfectly balanced one of its
+// live points.
+func (rt *RangeTree2D) rebuild(node *rangeNode) {
+	var (
+		points []*Point2D
+		parent = node.parent
+	)
+	var collect func(n *rangeNode)
+	collect = func(n *rangeNode) {
+		if n != nil {
+			collect(n.left)
+			if !n.point.dead {
+				points = append(points, n.point)
+			}
+			collect(n.right)
+		}
+	}
+	collect(node)
+
+	var dropped = node.size - len(points)
+	rt.size -= dropped
+	rt.dead -= dropped
+	for n := parent; n != nil; n = n.parent {
+		n.size -= dropped
+	}
+
+	built, _ := rt.build(points, parent)
+	if parent == nil {
+		rt.root = built
+	} else if parent.left == node {
+		parent.left = built
+	} else {
+		parent.right = built
+	}
+}
+
+// build builds a balanced subtree of points, which are in X order, and
+// returns it with its points in Y order.
+func (rt *RangeTree2D) build(points []*Point2D, parent *rangeNode) (*rangeNode, []interface{}) {
+	if len(points) == 0 {
+		return nil, nil
+	}
+	var (
+		mid  = len(points) / 2
+		node = &rangeNode{point: points[mid], parent: parent, size: len(points)}
+	)
+	points[mid].node = node
+	left, leftYs := rt.build(points[:mid], node)
+	right, rightYs := rt.build(points[mid+1:], node)
+	node.left, node.right = left, right
+
+	var ys = make([]interface{}, 0, len(points))
+	for _, p := range [][]interface{}{leftYs, {points[mid]}, rightYs} {
+		ys = rt.mergeYs(ys, p)
+	}
+	node.ys = (&FrozenTree{CmpFunc: rt.cmpY, keys: ys, vals: ys}).Thaw()
+	return node, ys
+}
+
+// mergeYs merges two lists of points in Y order.
+func (rt *RangeTree2D) mergeYs(a, b []interface{}) []interface{} {
+	var merged = make([]interface{}, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if rt.cmpY(b[0], a[0]) < 0 {
+			merged, b = append(merged, b[0]), b[1:]
+		} else {
+			merged, a = append(merged, a[0]), a[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
+
+// rectVisitor receives the parts of a rectangle query: the positions
+// [lo, hi) of a Y tree, or a single point.
+type rectVisitor struct {
+	subtree func(ys *BOSTree, lo, hi uint64)
+	point   func(p *Point2D)
+}
+
+// CountRect returns the number of points with x1 <= X <= x2 and
+// y1 <= Y <= y2, which is 0 if x2 is before x1 or y2 before y1.
+func (rt *RangeTree2D) CountRect(x1, x2, y1, y2 interface{}) uint64 {
+	var count uint64
+	rt.visitRect(x1, x2, y1, y2, rectVisitor{
+		subtree: func(ys *BOSTree, lo, hi uint64) {
+			count += hi - lo
+		},
+		point: func(p *Point2D) {
+			count++
+		},
+	})
+	return count
+}
+
+// ReportRect returns the points with x1 <= X <= x2 and y1 <= Y <= y2, in no
+// particular order. An inverted rectangle holds no points.
+func (rt *RangeTree2D) ReportRect(x1, x2, y1, y2 interface{}) []*Point2D {
+	var points []*Point2D
+	rt.visitRect(x1, x2, y1, y2, rectVisitor{
+		subtree: func(ys *BOSTree, lo, hi uint64) {
+			if lo < hi {
+				ys.Ascend(lo, func(key, val interface{}) bool {
+					points = append(points, val.(*Point2D))
+					lo++
+					return lo < hi
+				})
+			}
+		},
+		point: func(p *Point2D) {
+			points = append(points, p)
+		},
+	})
+	return points
+}
+
+func (rt *RangeTree2D) visitRect(x1, x2, y1, y2 interface{}, visitor rectVisitor) {
+	// The Y counts of a subtree are CountLessOrEqual(yHi) - CountLess(yLo),
+	// which would wrap around for y1 > y2.
+	if rt.XCmp(x1, x2) > 0 || rt.YCmp(y1, y2) > 0 {
+		return
+	}
+	var (
+		yLo = &Point2D{Y: y1, id: 0}
+		yHi = &Point2D{Y: y2, id: math.MaxUint64}
+	)
+	rt.visitNode(rt.root, x1, x2, yLo, yHi, false, false, visitor)
+}
+
+// visitNode splits [x1, x2] into O(log n) subtrees and single nodes below
+// node and passes the points in [yLo, yHi] to visitor. loIn and hiIn tell
+// whether the whole subtree is known to be at or after x1, and at or before
+// x2.
+func (rt *RangeTree2D) visitNode(node *rangeNode, x1, x2 interface{}, yLo, yHi *Point2D, loIn, hiIn bool, visitor rectVisitor) {
+	for node != nil {
+		if loIn && hiIn {
+			visitor.subtree(node.ys, node.ys.CountLess(yLo), node.ys.CountLessOrEqual(yHi))
+			return
+		}
+		if !loIn && rt.XCmp(node.point.X, x1) < 0 {
+			node = node.right
+			continue
+		}
+		if !hiIn && rt.XCmp(node.point.X, x2) > 0 {
+			node = node.left
+			continue
+		}
+
+		// node is within [x1, x2], so everything left of it is at or before
+		// x2 and everything right of it at or after x1.
+		if p := node.point; !p.dead && rt.YCmp(p.Y, yLo.Y) >= 0 && rt.YCmp(p.Y, yHi.Y) <= 0 {
+			visitor.point(p)
+		}
+		rt.visitNode(node.left, x1, x2, yLo, yHi, loIn, true, visitor)
+		node, loIn = node.right, true
+	}
+}
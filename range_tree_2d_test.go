@@ -0,0 +1,133 @@
+package bostree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// checkRangeNode checks the sizes and Y trees below node and returns the
+// number of outer nodes and live points there.
+func checkRangeNode(t *testing.T, rt *RangeTree2D, node *rangeNode) (int, uint64) {
+	if node == nil {
+		return 0, 0
+	}
+	var (
+		leftSize, leftLive   = checkRangeNode(t, rt, node.left)
+		rightSize, rightLive = checkRangeNode(t, rt, node.right)
+		size                 = leftSize + rightSize + 1
+		live                 = leftLive + rightLive
+	)
+	if !node.point.dead {
+		live++
+	}
+	if node.size != size || node.ys.NodeCount() != live {
+		t.Fatalf("Expected %d nodes and %d points, but got %d and %d\n", size, live, node.size, node.ys.NodeCount())
+	}
+	if node.point.node != node {
+		t.Fatalf("Expected the point to link back to its node\n")
+	}
+	return size, live
+}
+
+func rangeTreeDepth(node *rangeNode) int {
+	if node == nil {
+		return -1
+	}
+	return 1 + maxInt(rangeTreeDepth(node.left), rangeTreeDepth(node.right))
+}
+
+func TestRangeTree2D(t *testing.T) {
+	var (
+		intCmp = func(k1, k2 interface{}) int { return k1.(int) - k2.(int) }
+		rt     = NewRangeTree2D(intCmp, intCmp)
+		points []*Point2D
+		r      = rand.New(rand.NewSource(21))
+	)
+	for op := 0; op < 4000; op++ {
+		if len(points) == 0 || r.Intn(3) > 0 {
+			points = append(points, rt.Insert(r.Intn(100), r.Intn(100), op))
+		} else {
+			i := r.Intn(len(points))
+			rt.Remove(points[i])
+			points = append(points[:i], points[i+1:]...)
+		}
+
+		if op%500 == 0 || op == 3999 {
+			if _, live := checkRangeNode(t, rt, rt.root); live != uint64(len(points)) || rt.Len() != live {
+				t.Fatalf("Expected %d points, but got %d\n", len(points), rt.Len())
+			}
+			if depth, limit := rangeTreeDepth(rt.root), math.Log(float64(rt.size))/math.Log(1/rangeTreeAlpha)+1; float64(depth) > limit {
+				t.Fatalf("Expected depth at most %f, but got %d\n", limit, depth)
+			}
+		}
+	}
+
+	for q := 0; q < 300; q++ {
+		var (
+			x1, y1   = r.Intn(110) - 5, r.Intn(110) - 5
+			x2, y2   = x1 + r.Intn(40), y1 + r.Intn(40)
+			expected []int
+		)
+		// Some rectangles are inverted in X or Y and hold nothing.
+		switch q % 5 {
+		case 1:
+			x1, x2 = x2+1, x1
+		case 2:
+			y1, y2 = y2+1, y1
+		}
+		for _, p := range points {
+			if x, y := p.X.(int), p.Y.(int); x1 <= x && x <= x2 && y1 <= y && y <= y2 {
+				expected = append(expected, p.Val.(int))
+			}
+		}
+		if count := rt.CountRect(x1, x2, y1, y2); count != uint64(len(expected)) {
+			t.Fatalf("[%d, %d]x[%d, %d]: Expected %d, but got %d\n", x1, x2, y1, y2, len(expected), count)
+		}
+		var found []int
+		for _, p := range rt.ReportRect(x1, x2, y1, y2) {
+			found = append(found, p.Val.(int))
+		}
+		sort.Ints(expected)
+		sort.Ints(found)
+		if len(found) != len(expected) {
+			t.Fatalf("[%d, %d]x[%d, %d]: Expected %v, but got %v\n", x1, x2, y1, y2, expected, found)
+		}
+		for i := range found {
+			if found[i] != expected[i] {
+				t.Fatalf("[%d, %d]x[%d, %d]: Expected %v, but got %v\n", x1, x2, y1, y2, expected, found)
+			}
+		}
+	}
+
+	if count := rt.CountRect(0, 49, 5, 3); count != 0 {
+		t.Errorf("Expected 0 for an inverted Y range, but got %d\n", count)
+	}
+	if found := rt.ReportRect(49, 0, 3, 5); len(found) != 0 {
+		t.Errorf("Expected nothing for an inverted X range, but got %d points\n", len(found))
+	}
+
+	for _, p := range points {
+		rt.Remove(p)
+	}
+	if rt.Len() != 0 || rt.CountRect(0, 100, 0, 100) != 0 {
+		t.Errorf("Expected an empty tree, but got %d\n", rt.Len())
+	}
+}
+
+func BenchmarkRangeTree2D(b *testing.B) {
+	var (
+		intCmp = func(k1, k2 interface{}) int { return k1.(int) - k2.(int) }
+		rt     = NewRangeTree2D(intCmp, intCmp)
+		r      = rand.New(rand.NewSource(1))
+	)
+	for i := 0; i < 100000; i++ {
+		rt.Insert(r.Intn(1000000), r.Intn(1000000), nil)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := r.Intn(1000000), r.Intn(1000000)
+		rt.CountRect(x, x+100000, y, y+100000)
+	}
+}
@@ -0,0 +1,139 @@
+package bostree
+
+import (
+	. "github.com/bostree/bos_node"
+)
+
+// RankMode selects how RankWithTies ranks nodes with equal keys. Ranks are
+// 1-based; the examples rank the keys 10, 20, 20, 30.
+type RankMode int
+
+const (
+	// RankCompetition gives ties the best rank of the group: 1 2 2 4.
+	RankCompetition RankMode = iota
+	// RankModifiedCompetition gives ties the worst rank of the group: 1 3 3 4.
+	RankModifiedCompetition
+	// RankDense numbers distinct keys consecutively: 1 2 2 3. It needs
+	// TrackDistinct.
+	RankDense
+	// RankOrdinal is the node's position in the tree: 1 2 3 4.
+	RankOrdinal
+	// RankFractional gives ties the average of their positions: 1 2.5 2.5 4.
+	RankFractional
+)
+
+// LowerBound returns the first node whose key is not less than key.
+func (tree *BOSTree) LowerBound(key interface{}) *BOSNode {
+	node, _ := tree.bound(key, false)
+	return node
+}
+
+// UpperBound returns the first node whose key is greater than key.
+func (tree *BOSTree) UpperBound(key interface{}) *BOSNode {
+	node, _ := tree.bound(key, true)
+	return node
+}
+
+// CountLess returns the number of nodes whose key is less than key.
+func (tree *BOSTree) CountLess(key interface{}) uint64 {
+	_, count := tree.bound(key, false)
+	return count
+}
+
+// CountLessOrEqual returns the number of nodes whose key is less than or
+// equal to key.
+func (tree *BOSTree) CountLessOrEqual(key interface{}) uint64 {
+	_, count := tree.bound(key, true)
+	return count
+}
+
+// CountRange returns the number of nodes with lo <= key < hi.
+func (tree *BOSTree) CountRange(lo, hi interface{}) uint64 {
+	if tree.cmp(lo, hi) >= 0 {
+		return 0
+	}
+	return tree.CountLess(hi) - tree.CountLess(lo)
+}
+
+// RankOfKey returns the number of nodes whose key is less than key, which is
+// the 0-based position key would take if it were inserted before its equals.
+func (tree *BOSTree) RankOfKey(key interface{}) uint64 {
+	return tree.CountLess(key)
+}
+
+// Quantile returns the node at position floor(q*(n-1)), so Quantile(0.5) is
+// the lower median. It returns nil for an empty tree or q outside [0, 1].
+func (tree *BOSTree) Quantile(q float64) *BOSNode {
+	index, ok := quantileIndex(q, tree.NodeCount())
+	if !ok {
+		return nil
+	}
+	return tree.Select(index)
+}
+
+// quantileIndex maps q to a position among n entries.
+func quantileIndex(q float64, n uint64) (uint64, bool) {
+	if n == 0 || !(q >= 0 && q <= 1) {
+		return 0, false
+	}
+	return uint64(q * float64(n-1)), true
+}
+
+// TrackDistinct makes the tree keep its distinct keys in a Multiset, in
+// O(n log d) now and O(log d) more per Insert and Remove, so that RankDense
+// takes O(log d) for d distinct keys. A distinct count per subtree would not
+// fit in BOSNode, and Augment is left to the caller.
+func (tree *BOSTree) TrackDistinct() {
+	if tree.distinct != nil {
+		return
+	}
+	tree.distinct = NewMultiset(tree.CmpFunc)
+	tree.distinct.Tree.CheckCmp = tree.CheckCmp
+	for node := tree.Min(); node != nil; node = tree.NxtNode(node) {
+		tree.distinct.Add(node.Key, 1)
+	}
+}
+
+// RankWithTies returns the 1-based rank of node under mode in O(log n). It
+// panics for RankDense unless TrackDistinct was called.
+func (tree *BOSTree) RankWithTies(node *BOSNode, mode RankMode) float64 {
+	switch mode {
+	case RankDense:
+		if tree.distinct == nil {
+			panic("bostree: RankDense needs TrackDistinct")
+		}
+		return float64(tree.distinct.Tree.CountLess(node.Key) + 1)
+	case RankCompetition:
+		return float64(tree.CountLess(node.Key) + 1)
+	case RankModifiedCompetition:
+		return float64(tree.CountLessOrEqual(node.Key))
+	case RankFractional:
+		var (
+			first = tree.CountLess(node.Key) + 1
+			last  = tree.CountLessOrEqual(node.Key)
+		)
+		return float64(first+last) / 2
+	}
+	return float64(tree.Rank(node) + 1)
+}
+
+// bound descends to the first node whose key is not less than key (or, if
+// strict, greater than key) and returns it with the number of nodes before it.
+func (tree *BOSTree) bound(key interface{}, strict bool) (*BOSNode, uint64) {
+	var (
+		node  = tree.RootNode
+		found *BOSNode
+		count uint64 = 0
+	)
+	for node != nil {
+		cmp := tree.cmp(node.Key, key)
+		if cmp < 0 || (strict && cmp == 0) {
+			count += node.LeftChildCount() + 1
+			node = node.RightChildNode
+		} else {
+			found = node
+			node = node.LeftChildNode
+		}
+	}
+	return found, count
+}
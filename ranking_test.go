@@ -0,0 +1,76 @@
+package bostree
+
+import (
+	"testing"
+)
+
+func TestRankWithTies(t *testing.T) {
+	tree := Build(func(k1, k2 interface{}) int {
+		return k1.(int) - k2.(int)
+	})
+	for _, key := range []int{30, 20, 10, 20, 40, 40, 40} {
+		tree.Insert(key, nil)
+	}
+	tree.TrackDistinct()
+
+	var cases = []struct {
+		mode     RankMode
+		expected []float64
+	}{
+		{RankCompetition, []float64{1, 2, 2, 4, 5, 5, 5}},
+		{RankModifiedCompetition, []float64{1, 3, 3, 4, 7, 7, 7}},
+		{RankDense, []float64{1, 2, 2, 3, 4, 4, 4}},
+		{RankOrdinal, []float64{1, 2, 3, 4, 5, 6, 7}},
+		{RankFractional, []float64{1, 2.5, 2.5, 4, 6, 6, 6}},
+	}
+	for _, c := range cases {
+		for i, expected := range c.expected {
+			if rank := tree.RankWithTies(tree.Select(uint64(i)), c.mode); rank != expected {
+				t.Errorf("Mode %d, index %d: Expected %f, but got %f\n", c.mode, i, expected, rank)
+			}
+		}
+	}
+
+	t.Run("Bounds", func(t *testing.T) {
+		if n := tree.CountLess(20); n != 1 {
+			t.Errorf("Expected 1, but got %d\n", n)
+		}
+		if n := tree.CountLessOrEqual(25); n != 3 {
+			t.Errorf("Expected 3, but got %d\n", n)
+		}
+		if node := tree.LowerBound(35); node == nil || node.Key != 40 {
+			t.Errorf("Expected 40, but got %v\n", node)
+		}
+		if node := tree.UpperBound(40); node != nil {
+			t.Errorf("Expected nil, but got %v\n", node.Key)
+		}
+		if n := tree.CountRange(20, 40); n != 3 {
+			t.Errorf("Expected 3, but got %d\n", n)
+		}
+		if n := tree.CountRange(40, 20); n != 0 {
+			t.Errorf("Expected 0, but got %d\n", n)
+		}
+	})
+	t.Run("Dense After Updates", func(t *testing.T) {
+		tree.Insert(25, nil)
+		tree.Remove(tree.LookUp(10))
+		// Keys are now 20 20 25 30 40 40 40.
+		expected := []float64{1, 1, 2, 3, 4, 4, 4}
+		for i, e := range expected {
+			if rank := tree.RankWithTies(tree.Select(uint64(i)), RankDense); rank != e {
+				t.Errorf("Index %d: Expected %f, but got %f\n", i, e, rank)
+			}
+		}
+	})
+
+	t.Run("Dense Untracked", func(t *testing.T) {
+		untracked := Build(tree.CmpFunc)
+		node := untracked.Insert(1, nil)
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected a panic without TrackDistinct\n")
+			}
+		}()
+		untracked.RankWithTies(node, RankDense)
+	})
+}
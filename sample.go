@@ -0,0 +1,187 @@
+package bostree
+
+import (
+	"math/rand"
+	"sort"
+
+	. "github.com/bostree/bos_node"
+)
+
+// Sample returns k nodes drawn uniformly at random with src, each by Select
+// of a random position in O(log n). With replace, the k draws are
+// independent and come in the order drawn. Without it, k is capped at the
+// node count and the nodes are distinct and in key order.
+func (tree *BOSTree) Sample(src rand.Source, k int, replace bool) []*BOSNode {
+	return tree.samplePositions(src, 0, tree.NodeCount(), k, replace)
+}
+
+// SampleRange is Sample restricted to the nodes with lo <= key < hi.
+func (tree *BOSTree) SampleRange(src rand.Source, lo, hi interface{}, k int, replace bool) []*BOSNode {
+	var from = tree.CountLess(lo)
+	return tree.samplePositions(src, from, from+tree.CountRange(lo, hi), k, replace)
+}
+
+// samplePositions samples k of the positions [from, to).
+func (tree *BOSTree) samplePositions(src rand.Source, from, to uint64, k int, replace bool) []*BOSNode {
+	var (
+		rng = rand.New(src)
+		n   = to - from
+	)
+	if n == 0 || k <= 0 {
+		return nil
+	}
+	if replace {
+		var nodes = make([]*BOSNode, k)
+		for i := range nodes {
+			nodes[i] = tree.Select(from + uint64(rng.Int63n(int64(n))))
+		}
+		return nodes
+	}
+
+	if uint64(k) > n {
+		k = int(n)
+	}
+	// Floyd's algorithm picks k distinct positions with k draws.
+	var (
+		chosen    = make(map[uint64]bool, k)
+		positions = make([]uint64, 0, k)
+	)
+	for j := n - uint64(k); j < n; j++ {
+		var pos = uint64(rng.Int63n(int64(j + 1)))
+		if chosen[pos] {
+			pos = j
+		}
+		chosen[pos] = true
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+
+	var nodes = make([]*BOSNode, k)
+	for i, pos := range positions {
+		nodes[i] = tree.Select(from + pos)
+	}
+	return nodes
+}
+
+// Sample returns k nodes drawn with src with probability proportional to
+// their values, which must not be negative. With replace, the draws are
+// independent. Without it, the nodes are distinct and in the order drawn,
+// each drawn with probability proportional to its value among the nodes not
+// drawn yet; fewer than k are returned if the values run out. Sample only
+// reads the tree. A draw takes O(log n log k + k) for k drawn nodes.
+func (st *SumTree) Sample(src rand.Source, k int, replace bool) []*BOSNode {
+	var (
+		rng   = rand.New(src)
+		nodes []*BOSNode
+		drawn drawnSet
+	)
+	for len(nodes) < k {
+		var total = st.Total() - drawn.sum(0, len(drawn.positions))
+		if total <= 0 {
+			break
+		}
+		// 1 - Float64 is in (0, 1], so nodes of value 0 are never drawn.
+		node, pos := st.selectBySumExcluding((1-rng.Float64())*total, &drawn)
+		if node == nil {
+			// Rounding took the target past the last value left.
+			if node, pos = st.lastExcluding(&drawn); node == nil {
+				break
+			}
+		}
+		nodes = append(nodes, node)
+		if !replace {
+			drawn.add(pos, st.Value(node))
+		}
+	}
+	return nodes
+}
+
+// drawnSet holds the positions of the nodes drawn so far, in order, with the
+// prefix sums of their values, so a descent can leave them out of subtree
+// sums without changing the tree.
+type drawnSet struct {
+	positions []uint64
+	values    []float64
+	// prefix[i] is the sum of values[:i].
+	prefix []float64
+}
+
+func (d *drawnSet) add(pos uint64, value float64) {
+	var i = d.search(0, len(d.positions), pos)
+	d.positions = append(d.positions, 0)
+	copy(d.positions[i+1:], d.positions[i:])
+	d.positions[i] = pos
+	d.values = append(d.values, 0)
+	copy(d.values[i+1:], d.values[i:])
+	d.values[i] = value
+
+	d.prefix = append(d.prefix[:0], 0)
+	for _, v := range d.values {
+		d.prefix = append(d.prefix, d.prefix[len(d.prefix)-1]+v)
+	}
+}
+
+// search returns the first index in [a, b) whose position is at least pos.
+func (d *drawnSet) search(a, b int, pos uint64) int {
+	return a + sort.Search(b-a, func(i int) bool { return d.positions[a+i] >= pos })
+}
+
+// sum returns the sum of the values at positions[a:b].
+func (d *drawnSet) sum(a, b int) float64 {
+	if a == b {
+		return 0
+	}
+	return d.prefix[b] - d.prefix[a]
+}
+
+func (d *drawnSet) contains(pos uint64) bool {
+	var i = d.search(0, len(d.positions), pos)
+	return i < len(d.positions) && d.positions[i] == pos
+}
+
+// selectBySumExcluding is SelectBySum with the values of the drawn nodes
+// taken as 0, and also returns the position of the node. The drawn nodes
+// within the subtree being descended are always positions[a:b].
+func (st *SumTree) selectBySumExcluding(target float64, drawn *drawnSet) (*BOSNode, uint64) {
+	var (
+		node = st.Tree.RootNode
+		lo   uint64
+		a, b = 0, len(drawn.positions)
+	)
+	for node != nil {
+		var (
+			pos     = lo + node.LeftChildCount()
+			m       = drawn.search(a, b, pos)
+			leftSum = subtreeSum(node.LeftChildNode) - drawn.sum(a, m)
+		)
+		if node.HasLeftChild() && leftSum >= target {
+			node, b = node.LeftChildNode, m
+			continue
+		}
+		target -= leftSum
+		var value = st.Value(node)
+		if m < b && drawn.positions[m] == pos {
+			value = 0
+			m++
+		}
+		if value > 0 && value >= target {
+			return node, pos
+		}
+		target -= value
+		node, lo, a = node.RightChildNode, pos+1, m
+	}
+	return nil, 0
+}
+
+// lastExcluding returns the last node with a positive value that has not
+// been drawn, and its position.
+func (st *SumTree) lastExcluding(drawn *drawnSet) (*BOSNode, uint64) {
+	var pos = st.Tree.NodeCount()
+	for node := st.Tree.Max(); node != nil; node = st.Tree.PrevNode(node) {
+		pos--
+		if st.Value(node) > 0 && !drawn.contains(pos) {
+			return node, pos
+		}
+	}
+	return nil, 0
+}
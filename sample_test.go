@@ -0,0 +1,146 @@
+package bostree
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	tree := intTree()
+	for key := 0; key < 10; key++ {
+		tree.Insert(key, nil)
+	}
+
+	nodes := tree.Sample(rand.NewSource(1), 20, false)
+	if len(nodes) != 10 {
+		t.Fatalf("Expected all 10 nodes, but got %d\n", len(nodes))
+	}
+	for i, node := range nodes {
+		if node.Key != i {
+			t.Errorf("Expected the nodes in key order, but got %d at %d\n", node.Key, i)
+		}
+	}
+
+	var (
+		first  = tree.Sample(rand.NewSource(2), 4, true)
+		second = tree.Sample(rand.NewSource(2), 4, true)
+	)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("Expected the same sample from the same seed\n")
+		}
+	}
+
+	// Every key should be drawn about as often, with and without
+	// replacement.
+	for _, replace := range []bool{true, false} {
+		var (
+			src    = rand.NewSource(3)
+			counts = make([]int, 10)
+		)
+		for i := 0; i < 10000; i++ {
+			for _, node := range tree.Sample(src, 3, replace) {
+				counts[node.Key.(int)]++
+			}
+		}
+		for key, count := range counts {
+			if math.Abs(float64(count)-3000) > 200 {
+				t.Errorf("Replace %v: Expected about 3000 draws of %d, but got %d\n", replace, key, count)
+			}
+		}
+	}
+
+	nodes = tree.SampleRange(rand.NewSource(4), 3, 6, 5, false)
+	if len(nodes) != 3 || nodes[0].Key != 3 || nodes[2].Key != 5 {
+		t.Errorf("Expected keys 3 to 5, but got %v\n", nodes)
+	}
+	for _, node := range tree.SampleRange(rand.NewSource(5), 3, 6, 50, true) {
+		if key := node.Key.(int); key < 3 || key >= 6 {
+			t.Errorf("Expected keys in [3, 6), but got %d\n", key)
+		}
+	}
+	if nodes := tree.SampleRange(rand.NewSource(6), 6, 3, 5, true); nodes != nil {
+		t.Errorf("Expected nil, but got %v\n", nodes)
+	}
+}
+
+func TestSumTreeSample(t *testing.T) {
+	st := NewSumTree(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+	for key := 0; key < 8; key++ {
+		// Keys 0 and 4 have no weight.
+		st.Insert(key, float64(key%4))
+	}
+	total := st.Total()
+
+	var (
+		src    = rand.NewSource(7)
+		counts = make([]int, 8)
+	)
+	for _, node := range st.Sample(src, 60000, true) {
+		counts[node.Key.(int)]++
+	}
+	for key, count := range counts {
+		if expected := 60000 * float64(key%4) / total; math.Abs(float64(count)-expected) > 300 {
+			t.Errorf("Expected about %.0f draws of %d, but got %d\n", expected, key, count)
+		}
+	}
+
+	nodes := st.Sample(src, 10, false)
+	if len(nodes) != 6 {
+		t.Fatalf("Expected the 6 weighted nodes, but got %d\n", len(nodes))
+	}
+	seen := map[int]bool{}
+	for _, node := range nodes {
+		if key := node.Key.(int); key%4 == 0 || seen[key] {
+			t.Errorf("Expected distinct keys with weight, but got %d\n", key)
+		}
+		seen[node.Key.(int)] = true
+	}
+	if st.Total() != total {
+		t.Errorf("Expected the total %f to stay, but got %f\n", total, st.Total())
+	}
+	checkSums(t, st.Tree.RootNode)
+
+	t.Run("Without Replacement", func(t *testing.T) {
+		st := NewSumTree(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+		for key := 1; key <= 3; key++ {
+			st.Insert(key, float64(key))
+		}
+		// Each pair is drawn one node at a time, in proportion to the values
+		// left: key 3 is missing from the pair with probability
+		// 1/6*2/5 + 2/6*1/4 = 0.15, key 2 with 0.8/3 and key 1 with 0.35/0.6.
+		var (
+			src      = rand.NewSource(8)
+			counts   = make([]int, 4)
+			expected = []float64{0, 30000 * (1 - 0.35/0.6), 30000 * (1 - 0.8/3), 30000 * 0.85}
+		)
+		for i := 0; i < 30000; i++ {
+			for _, node := range st.Sample(src, 2, false) {
+				counts[node.Key.(int)]++
+			}
+		}
+		for key := 1; key <= 3; key++ {
+			if math.Abs(float64(counts[key])-expected[key]) > 400 {
+				t.Errorf("Expected about %.0f samples with %d, but got %d\n", expected[key], key, counts[key])
+			}
+		}
+	})
+
+	t.Run("Concurrent Readers", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				src := rand.NewSource(int64(g))
+				for i := 0; i < 100; i++ {
+					st.Sample(src, 4, false)
+					st.PrefixSum(5)
+				}
+			}(g)
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,195 @@
+package bostree
+
+import (
+	"math"
+
+	. "github.com/bostree/bos_node"
+)
+
+// Sequence is an indexable list on a BOSTree without keys: positions come
+// from the subtree counts alone, so inserting or removing anywhere takes
+// O(log n). The nodes returned by InsertAt are handles that keep track of
+// their position as the sequence changes.
+type Sequence struct {
+	tree *BOSTree
+}
+
+func NewSequence() *Sequence {
+	return &Sequence{tree: Build(nil)}
+}
+
+func (seq *Sequence) Len() uint64 {
+	return seq.tree.NodeCount()
+}
+
+// InsertAt inserts val so it ends up at position index, and returns its
+// handle. It returns nil if index is greater than Len.
+func (seq *Sequence) InsertAt(index uint64, val interface{}) *BOSNode {
+	var tree = seq.tree
+	if index > tree.NodeCount() {
+		return nil
+	}
+	if tree.NodeCount() == math.MaxUint32 {
+		panic("bostree: tree is full")
+	}
+
+	var (
+		node       = tree.RootNode
+		parentNode *BOSNode
+		left       bool
+	)
+	for node != nil {
+		parentNode = node
+		node.Size++
+		if leftCount := node.LeftChildCount(); index <= leftCount {
+			left = true
+			node = node.LeftChildNode
+		} else {
+			left = false
+			index -= leftCount + 1
+			node = node.RightChildNode
+		}
+	}
+
+	var newNode = tree.newNode()
+	newNode.Val = val
+	tree.update(newNode)
+	if parentNode == nil {
+		tree.RootNode = newNode
+		return newNode
+	}
+	if left {
+		parentNode.LeftChildNode = newNode
+	} else {
+		parentNode.RightChildNode = newNode
+	}
+	newNode.ParentNode = parentNode
+	tree.bubbleUp(parentNode, 0)
+	return newNode
+}
+
+// RemoveAt removes the value at position index and returns it.
+func (seq *Sequence) RemoveAt(index uint64) (interface{}, bool) {
+	var node = seq.tree.Select(index)
+	if node == nil {
+		return nil, false
+	}
+	var val = node.Val
+	seq.tree.Remove(node)
+	return val, true
+}
+
+// Remove removes the value of handle from the sequence.
+func (seq *Sequence) Remove(handle *BOSNode) {
+	seq.tree.Remove(handle)
+}
+
+func (seq *Sequence) At(index uint64) (interface{}, bool) {
+	var node = seq.tree.Select(index)
+	if node == nil {
+		return nil, false
+	}
+	return node.Val, true
+}
+
+// IndexOf returns the current position of handle.
+func (seq *Sequence) IndexOf(handle *BOSNode) uint64 {
+	return seq.tree.Rank(handle)
+}
+
+// Slice returns the values at positions [i, j), with j capped at Len.
+func (seq *Sequence) Slice(i, j uint64) []interface{} {
+	if j > seq.Len() {
+		j = seq.Len()
+	}
+	if i >= j {
+		return nil
+	}
+	var vals = make([]interface{}, 0, j-i)
+	seq.tree.Ascend(i, func(key, val interface{}) bool {
+		vals = append(vals, val)
+		return uint64(len(vals)) < j-i
+	})
+	return vals
+}
+
+// Concat moves all values of other to the end of seq in O(log n), leaving
+// other empty. Handles into other stay valid and now point into seq.
+func (seq *Sequence) Concat(other *Sequence) {
+	if other == seq || other.Len() == 0 {
+		return
+	}
+	if seq.Len()+other.Len() > math.MaxUint32 {
+		panic("bostree: tree is full")
+	}
+	if seq.Len() == 0 {
+		seq.tree.RootNode, other.tree.RootNode = other.tree.RootNode, nil
+		return
+	}
+
+	// The first node of other joins both trees as their new middle.
+	var mid = other.tree.Select(0)
+	other.tree.Remove(mid)
+	*mid = BOSNode{Val: mid.Val, Size: 1}
+	seq.tree.join(mid, other.tree.RootNode)
+	other.tree.RootNode = nil
+}
+
+// join appends mid and then the tree at right to the tree, rebalancing only
+// along one spine. mid must be a detached single node.
+func (tree *BOSTree) join(mid, right *BOSNode) {
+	var (
+		left   = tree.RootNode
+		parent *BOSNode
+		delta  int64
+	)
+	// Descend along the spine of the taller tree to the first subtree that
+	// is at most one level taller than the other tree, and put mid there.
+	if height(left) >= height(right) {
+		for height(left) > height(right)+1 {
+			parent, left = left, left.RightChildNode
+		}
+		if parent != nil {
+			parent.RightChildNode = mid
+		}
+		delta = 1 + int64(sizeOf(right))
+	} else {
+		tree.RootNode = right
+		for height(right) > height(left)+1 {
+			parent, right = right, right.LeftChildNode
+		}
+		if parent != nil {
+			parent.LeftChildNode = mid
+		}
+		delta = 1 + int64(sizeOf(left))
+	}
+	if parent == nil {
+		tree.RootNode = mid
+	}
+
+	mid.ParentNode = parent
+	mid.LeftChildNode, mid.RightChildNode = left, right
+	if left != nil {
+		left.ParentNode = mid
+	}
+	if right != nil {
+		right.ParentNode = mid
+	}
+	// Above mid, the subtrees have grown by the smaller tree and mid.
+	tree.bubbleUp(mid, delta)
+}
+
+// height returns the height of the subtree at node, -1 for an empty one.
+func height(node *BOSNode) int {
+	if node == nil {
+		return -1
+	}
+	return int(node.Depth)
+}
+
+func sizeOf(node *BOSNode) uint32 {
+	if node == nil {
+		return 0
+	}
+	return node.Size
+}
@@ -0,0 +1,100 @@
+package bostree
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/bostree/bos_node"
+)
+
+// checkSequence compares seq with ref and checks the AVL invariants.
+func checkSequence(t *testing.T, seq *Sequence, ref []int) {
+	if seq.Len() != uint64(len(ref)) {
+		t.Fatalf("Expected %d, but got %d\n", len(ref), seq.Len())
+	}
+	for i, val := range seq.Slice(0, seq.Len()) {
+		if val != ref[i] {
+			t.Fatalf("Expected %d at %d, but got %v\n", ref[i], i, val)
+		}
+	}
+	var node = seq.tree.Select(0)
+	for ; node != nil; node = seq.tree.NxtNode(node) {
+		if balance := BOSTreeBalance(node); balance < -1 || balance > 1 {
+			t.Fatalf("Expected a balanced tree, but got %d\n", balance)
+		}
+		if uint64(node.Depth) != actualDepth(node) || uint64(node.Size) != actualCount(node) {
+			t.Fatalf("Expected depth %d and size %d, but got %d and %d\n", actualDepth(node), actualCount(node), node.Depth, node.Size)
+		}
+	}
+}
+
+func TestSequence(t *testing.T) {
+	var (
+		seq = NewSequence()
+		ref []int
+		r   = rand.New(rand.NewSource(11))
+	)
+	for op := 0; op < 5000; op++ {
+		if len(ref) == 0 || r.Intn(3) > 0 {
+			i := r.Intn(len(ref) + 1)
+			handle := seq.InsertAt(uint64(i), op)
+			ref = append(ref, 0)
+			copy(ref[i+1:], ref[i:])
+			ref[i] = op
+			if index := seq.IndexOf(handle); index != uint64(i) {
+				t.Fatalf("Expected IndexOf = %d, but got %d\n", i, index)
+			}
+		} else {
+			i := r.Intn(len(ref))
+			if val, ok := seq.RemoveAt(uint64(i)); !ok || val != ref[i] {
+				t.Fatalf("Expected RemoveAt(%d) = %d, but got %v\n", i, ref[i], val)
+			}
+			ref = append(ref[:i], ref[i+1:]...)
+		}
+	}
+	checkSequence(t, seq, ref)
+
+	if seq.InsertAt(seq.Len()+1, nil) != nil {
+		t.Errorf("Expected InsertAt past the end to fail\n")
+	}
+	if _, ok := seq.At(seq.Len()); ok {
+		t.Errorf("Expected At past the end to fail\n")
+	}
+	if vals := seq.Slice(10, 13); len(vals) != 3 || vals[0] != ref[10] || vals[2] != ref[12] {
+		t.Errorf("Expected %v, but got %v\n", ref[10:13], vals)
+	}
+	if vals := seq.Slice(5, 5); vals != nil {
+		t.Errorf("Expected no values, but got %v\n", vals)
+	}
+}
+
+func TestSequenceConcat(t *testing.T) {
+	r := rand.New(rand.NewSource(12))
+	for round := 0; round < 200; round++ {
+		var (
+			seqs    [2]*Sequence
+			refs    [2][]int
+			handles []*BOSNode
+		)
+		for s := range seqs {
+			seqs[s] = NewSequence()
+			n := r.Intn(1 << uint(r.Intn(10)))
+			for i := 0; i < n; i++ {
+				val := s*100000 + i
+				handles = append(handles, seqs[s].InsertAt(uint64(i), val))
+				refs[s] = append(refs[s], val)
+			}
+		}
+		seqs[0].Concat(seqs[1])
+		ref := append(refs[0], refs[1]...)
+		checkSequence(t, seqs[0], ref)
+		if seqs[1].Len() != 0 {
+			t.Fatalf("Expected the other sequence to be empty, but got %d\n", seqs[1].Len())
+		}
+		for i, handle := range handles {
+			if index := seqs[0].IndexOf(handle); index != uint64(i) {
+				t.Fatalf("Expected handle %d to stay valid, but got index %d\n", i, index)
+			}
+		}
+	}
+}
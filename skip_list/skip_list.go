@@ -0,0 +1,217 @@
+// Package skip_list is a counted (indexable) skip list implementing
+// bostree.OrderedIndex.
+//
+// Every link stores how many positions it skips, so Select and Rank add up
+// link widths on the way down like BOSTree adds up subtree counts. Inserts
+// and removes only touch the links around one node and never rebalance.
+package skip_list
+
+import (
+	"github.com/bostree"
+)
+
+const (
+	maxLevel = 32
+	// A node reaches the next level with probability 1/4.
+	levelBits = 2
+)
+
+type skipNode struct {
+	key, val interface{}
+	next     []*skipNode
+	// width[i] is the distance in positions to next[i]; a nil link points at
+	// a virtual tail one past the last entry.
+	width []uint64
+}
+
+type SkipList struct {
+	CmpFunc func(k1, k2 interface{}) int
+	head    *skipNode
+	level   int
+	length  uint64
+	seed    uint64
+}
+
+var _ bostree.OrderedIndex = (*SkipList)(nil)
+
+func Build(cmp_func func(k1, k2 interface{}) int) *SkipList {
+	var list = &SkipList{
+		CmpFunc: cmp_func,
+		head: &skipNode{
+			next:  make([]*skipNode, maxLevel),
+			width: make([]uint64, maxLevel),
+		},
+		level: 1,
+		seed:  0x9e3779b97f4a7c15,
+	}
+	// The empty list's tail sits right after the head.
+	list.head.width[0] = 1
+	return list
+}
+
+func (list *SkipList) Len() uint64 {
+	return list.length
+}
+
+// randomLevel draws a geometric level from a xorshift generator.
+func (list *SkipList) randomLevel() int {
+	list.seed ^= list.seed << 13
+	list.seed ^= list.seed >> 7
+	list.seed ^= list.seed << 17
+	var (
+		level = 1
+		bits  = list.seed
+	)
+	for level < maxLevel && bits&(1<<levelBits-1) == 0 {
+		level++
+		bits >>= levelBits
+	}
+	return level
+}
+
+// findLast fills update with the last node before key at every level, and
+// pos with their positions. Nodes equal to key are passed over if after is
+// set. It returns the position of update[0].
+func (list *SkipList) findLast(key interface{}, after bool, update *[maxLevel]*skipNode, pos *[maxLevel]uint64) uint64 {
+	var (
+		x = list.head
+		p uint64
+	)
+	for i := list.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			cmp := list.CmpFunc(x.next[i].key, key)
+			if cmp > 0 || (cmp == 0 && !after) {
+				break
+			}
+			p += x.width[i]
+			x = x.next[i]
+		}
+		update[i], pos[i] = x, p
+	}
+	return p
+}
+
+func (list *SkipList) Insert(key, val interface{}) {
+	var (
+		update [maxLevel]*skipNode
+		pos    [maxLevel]uint64
+		p      = list.findLast(key, true, &update, &pos)
+		level  = list.randomLevel()
+	)
+	if level > list.level {
+		for i := list.level; i < level; i++ {
+			update[i], pos[i] = list.head, 0
+			list.head.width[i] = list.length + 1
+		}
+		list.level = level
+	}
+
+	var x = &skipNode{
+		key:   key,
+		val:   val,
+		next:  make([]*skipNode, level),
+		width: make([]uint64, level),
+	}
+	for i := 0; i < level; i++ {
+		x.next[i] = update[i].next[i]
+		update[i].next[i] = x
+		x.width[i] = update[i].width[i] - (p - pos[i])
+		update[i].width[i] = p - pos[i] + 1
+	}
+	for i := level; i < list.level; i++ {
+		update[i].width[i]++
+	}
+	list.length++
+}
+
+func (list *SkipList) Remove(key interface{}) bool {
+	var (
+		update [maxLevel]*skipNode
+		pos    [maxLevel]uint64
+	)
+	list.findLast(key, false, &update, &pos)
+	var x = update[0].next[0]
+	if x == nil || list.CmpFunc(x.key, key) != 0 {
+		return false
+	}
+
+	for i := 0; i < list.level; i++ {
+		if update[i].next[i] == x {
+			update[i].width[i] += x.width[i] - 1
+			update[i].next[i] = x.next[i]
+		} else {
+			update[i].width[i]--
+		}
+	}
+	for list.level > 1 && list.head.next[list.level-1] == nil {
+		list.level--
+	}
+	list.length--
+	return true
+}
+
+func (list *SkipList) LookUp(key interface{}) (interface{}, bool) {
+	var x = list.head
+	for i := list.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && list.CmpFunc(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+	}
+	x = x.next[0]
+	if x == nil || list.CmpFunc(x.key, key) != 0 {
+		return nil, false
+	}
+	return x.val, true
+}
+
+func (list *SkipList) Select(index uint64) (key, val interface{}, ok bool) {
+	var x = list.node(index)
+	if x == nil {
+		return nil, nil, false
+	}
+	return x.key, x.val, true
+}
+
+func (list *SkipList) Rank(key interface{}) uint64 {
+	var (
+		x = list.head
+		p uint64
+	)
+	for i := list.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && list.CmpFunc(x.next[i].key, key) < 0 {
+			p += x.width[i]
+			x = x.next[i]
+		}
+	}
+	return p
+}
+
+func (list *SkipList) Ascend(from uint64, fn func(key, val interface{}) bool) {
+	for x := list.node(from); x != nil; x = x.next[0] {
+		if !fn(x.key, x.val) {
+			return
+		}
+	}
+}
+
+// node returns the entry at position index, or nil.
+func (list *SkipList) node(index uint64) *skipNode {
+	if index >= list.length {
+		return nil
+	}
+	var (
+		x      = list.head
+		p      uint64
+		target = index + 1
+	)
+	for i := list.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && p+x.width[i] <= target {
+			p += x.width[i]
+			x = x.next[i]
+		}
+		if p == target {
+			return x
+		}
+	}
+	return nil
+}
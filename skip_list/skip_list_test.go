@@ -0,0 +1,37 @@
+package skip_list
+
+import (
+	"testing"
+
+	"github.com/bostree"
+	"github.com/bostree/index_conformance"
+)
+
+func TestSkipListConformance(t *testing.T) {
+	index_conformance.Run(t, func(cmp func(k1, k2 interface{}) int) bostree.OrderedIndex {
+		return Build(cmp)
+	})
+}
+
+func TestSkipListWidths(t *testing.T) {
+	var list = Build(func(k1, k2 interface{}) int {
+		return k1.(int) - k2.(int)
+	})
+	for i := 0; i < 5000; i++ {
+		list.Insert((i*7919)%5000, nil)
+	}
+	for i := 0; i < 5000; i += 3 {
+		list.Remove(i)
+	}
+
+	// Every level has to add up to the distance from the head to the tail.
+	for i := 0; i < list.level; i++ {
+		var total uint64
+		for x := list.head; x != nil; x = x.next[i] {
+			total += x.width[i]
+		}
+		if total != list.length+1 {
+			t.Errorf("Level %d: Expected %d, but got %d\n", i, list.length+1, total)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package bostree
+
+import (
+	"errors"
+	"math"
+
+	. "github.com/bostree/bos_node"
+)
+
+// ZAddFlag mirrors the option flags of the Redis ZADD command.
+type ZAddFlag uint8
+
+const (
+	// ZAddNX only adds new members and never updates existing ones.
+	ZAddNX ZAddFlag = 1 << iota
+	// ZAddXX only updates existing members and never adds new ones.
+	ZAddXX
+	// ZAddGT only updates existing members if the new score is greater.
+	ZAddGT
+	// ZAddLT only updates existing members if the new score is less.
+	ZAddLT
+	// ZAddCH counts changed members as well as added ones in the result.
+	ZAddCH
+)
+
+// ZMember is a member of a SortedSet together with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// ScoreBound is one end of a score interval, as in "(1.5" or "1.5" in Redis.
+type ScoreBound struct {
+	Value     float64
+	Exclusive bool
+}
+
+// zsetKey orders the underlying tree by (score, member), like Redis does.
+type zsetKey struct {
+	score  float64
+	member string
+}
+
+func zsetCmp(k1, k2 interface{}) int {
+	var (
+		z1 = k1.(zsetKey)
+		z2 = k2.(zsetKey)
+	)
+	if z1.score < z2.score {
+		return -1
+	}
+	if z1.score > z2.score {
+		return 1
+	}
+	if z1.member < z2.member {
+		return -1
+	}
+	if z1.member > z2.member {
+		return 1
+	}
+	return 0
+}
+
+// SortedSet is a Redis-style sorted set: a member -> node hash index over a
+// BOSTree ordered by (score, member).
+type SortedSet struct {
+	tree *BOSTree
+	dict map[string]*BOSNode
+}
+
+func NewSortedSet() *SortedSet {
+	return &SortedSet{
+		tree: Build(zsetCmp),
+		dict: make(map[string]*BOSNode),
+	}
+}
+
+func (zs *SortedSet) ZCard() uint64 {
+	return zs.tree.NodeCount()
+}
+
+// ZAdd adds or updates members according to flags. It returns the number of
+// added members, plus the number of updated members if ZAddCH is set.
+func (zs *SortedSet) ZAdd(flags ZAddFlag, members ...ZMember) (int, error) {
+	if flags&ZAddNX != 0 && flags&ZAddXX != 0 {
+		return 0, errors.New("XX and NX options at the same time are not compatible")
+	}
+	if (flags&ZAddGT != 0 && flags&ZAddLT != 0) ||
+		(flags&ZAddNX != 0 && flags&(ZAddGT|ZAddLT) != 0) {
+		return 0, errors.New("GT, LT, and/or NX options at the same time are not compatible")
+	}
+	for _, m := range members {
+		if math.IsNaN(m.Score) {
+			return 0, errors.New("value is not a valid float")
+		}
+	}
+
+	var (
+		added   = 0
+		changed = 0
+	)
+	for _, m := range members {
+		node, ok := zs.dict[m.Member]
+		if !ok {
+			if flags&ZAddXX != 0 {
+				continue
+			}
+			zs.insert(m.Member, m.Score)
+			added++
+			continue
+		}
+		if flags&ZAddNX != 0 {
+			continue
+		}
+		cur := node.Key.(zsetKey).score
+		if flags&ZAddGT != 0 && m.Score <= cur {
+			continue
+		}
+		if flags&ZAddLT != 0 && m.Score >= cur {
+			continue
+		}
+		if m.Score != cur {
+			zs.tree.Remove(node)
+			zs.insert(m.Member, m.Score)
+			changed++
+		}
+	}
+
+	if flags&ZAddCH != 0 {
+		return added + changed, nil
+	}
+	return added, nil
+}
+
+// ZIncrBy increments the score of member by incr, adding the member with a
+// score of incr if it does not exist yet.
+func (zs *SortedSet) ZIncrBy(incr float64, member string) (float64, error) {
+	var score = incr
+	node, ok := zs.dict[member]
+	if ok {
+		score += node.Key.(zsetKey).score
+	}
+	if math.IsNaN(score) {
+		return 0, errors.New("resulting score is not a number (NaN)")
+	}
+	if ok {
+		zs.tree.Remove(node)
+	}
+	zs.insert(member, score)
+	return score, nil
+}
+
+// ZRem removes the given members and returns how many of them existed.
+func (zs *SortedSet) ZRem(members ...string) int {
+	var removed = 0
+	for _, member := range members {
+		if node, ok := zs.dict[member]; ok {
+			zs.tree.Remove(node)
+			delete(zs.dict, member)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (zs *SortedSet) ZScore(member string) (float64, bool) {
+	node, ok := zs.dict[member]
+	if !ok {
+		return 0, false
+	}
+	return node.Key.(zsetKey).score, true
+}
+
+// ZRank returns the 0-based rank of member, ordered from the lowest score.
+func (zs *SortedSet) ZRank(member string) (uint64, bool) {
+	node, ok := zs.dict[member]
+	if !ok {
+		return 0, false
+	}
+	return zs.tree.Rank(node), true
+}
+
+// ZRevRank returns the 0-based rank of member, ordered from the highest score.
+func (zs *SortedSet) ZRevRank(member string) (uint64, bool) {
+	rank, ok := zs.ZRank(member)
+	if !ok {
+		return 0, false
+	}
+	return zs.ZCard() - 1 - rank, true
+}
+
+// ZRange returns the members with ranks in [start, stop]. Negative indexes
+// count from the end, -1 being the member with the highest score.
+func (zs *SortedSet) ZRange(start, stop int64) []ZMember {
+	lo, hi, ok := zs.rankRange(start, stop)
+	if !ok {
+		return nil
+	}
+	var (
+		result = make([]ZMember, 0, hi-lo+1)
+		node   = zs.tree.Select(lo)
+	)
+	for i := lo; i <= hi; i++ {
+		result = append(result, zmember(node))
+		node = zs.tree.NxtNode(node)
+	}
+	return result
+}
+
+// ZRevRange is ZRange with ranks ordered from the highest score.
+func (zs *SortedSet) ZRevRange(start, stop int64) []ZMember {
+	lo, hi, ok := zs.rankRange(start, stop)
+	if !ok {
+		return nil
+	}
+	var (
+		n      = zs.ZCard()
+		result = make([]ZMember, 0, hi-lo+1)
+		node   = zs.tree.Select(n - 1 - lo)
+	)
+	for i := lo; i <= hi; i++ {
+		result = append(result, zmember(node))
+		node = zs.tree.PrevNode(node)
+	}
+	return result
+}
+
+// ZRangeByScore returns the members with scores between min and max. It skips
+// the first offset matches and returns at most count of them; a negative
+// count returns all remaining matches.
+func (zs *SortedSet) ZRangeByScore(min, max ScoreBound, offset, count int64) []ZMember {
+	lo, hi := zs.scoreRange(min, max)
+	if offset < 0 || lo+uint64(offset) >= hi {
+		return nil
+	}
+	lo += uint64(offset)
+	if count >= 0 && hi-lo > uint64(count) {
+		hi = lo + uint64(count)
+	}
+
+	var (
+		result = make([]ZMember, 0, hi-lo)
+		node   = zs.tree.Select(lo)
+	)
+	for i := lo; i < hi; i++ {
+		result = append(result, zmember(node))
+		node = zs.tree.NxtNode(node)
+	}
+	return result
+}
+
+// ZRevRangeByScore is ZRangeByScore with the matches ordered from the highest
+// score, so offset skips the highest ones.
+func (zs *SortedSet) ZRevRangeByScore(min, max ScoreBound, offset, count int64) []ZMember {
+	lo, hi := zs.scoreRange(min, max)
+	if offset < 0 || lo+uint64(offset) >= hi {
+		return nil
+	}
+	hi -= uint64(offset)
+	if count >= 0 && hi-lo > uint64(count) {
+		lo = hi - uint64(count)
+	}
+
+	var (
+		result = make([]ZMember, 0, hi-lo)
+		node   = zs.tree.Select(hi - 1)
+	)
+	for i := lo; i < hi; i++ {
+		result = append(result, zmember(node))
+		node = zs.tree.PrevNode(node)
+	}
+	return result
+}
+
+// ZCount returns the number of members with scores between min and max.
+func (zs *SortedSet) ZCount(min, max ScoreBound) uint64 {
+	lo, hi := zs.scoreRange(min, max)
+	return hi - lo
+}
+
+// ZRemRangeByRank removes the members with ranks in [start, stop] and returns
+// how many were removed.
+func (zs *SortedSet) ZRemRangeByRank(start, stop int64) int {
+	lo, hi, ok := zs.rankRange(start, stop)
+	if !ok {
+		return 0
+	}
+	var node = zs.tree.Select(lo)
+	for i := lo; i <= hi; i++ {
+		next := zs.tree.NxtNode(node)
+		delete(zs.dict, node.Key.(zsetKey).member)
+		zs.tree.Remove(node)
+		node = next
+	}
+	return int(hi - lo + 1)
+}
+
+func (zs *SortedSet) insert(member string, score float64) {
+	zs.dict[member] = zs.tree.Insert(zsetKey{score: score, member: member}, nil)
+}
+
+// rankRange clamps a Redis-style [start, stop] rank interval to the set.
+func (zs *SortedSet) rankRange(start, stop int64) (uint64, uint64, bool) {
+	var n = int64(zs.ZCard())
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	return uint64(start), uint64(stop), true
+}
+
+// scoreRange returns the rank interval [lo, hi) of the members with scores
+// between min and max.
+func (zs *SortedSet) scoreRange(min, max ScoreBound) (uint64, uint64) {
+	var (
+		lo = zs.rankAfter(func(score float64) bool {
+			return score < min.Value || (min.Exclusive && score == min.Value)
+		})
+		hi = zs.rankAfter(func(score float64) bool {
+			return score < max.Value || (!max.Exclusive && score == max.Value)
+		})
+	)
+	if hi < lo {
+		return lo, lo
+	}
+	return lo, hi
+}
+
+// rankAfter returns the rank of the first member whose score does not satisfy
+// before, which must hold for a (possibly empty) prefix of the set.
+func (zs *SortedSet) rankAfter(before func(score float64) bool) uint64 {
+	var (
+		node  = zs.tree.RootNode
+		found *BOSNode
+	)
+	for node != nil {
+		if before(node.Key.(zsetKey).score) {
+			node = node.RightChildNode
+		} else {
+			found = node
+			node = node.LeftChildNode
+		}
+	}
+	if found == nil {
+		return zs.ZCard()
+	}
+	return zs.tree.Rank(found)
+}
+
+func zmember(node *BOSNode) ZMember {
+	var key = node.Key.(zsetKey)
+	return ZMember{Member: key.member, Score: key.score}
+}
@@ -0,0 +1,148 @@
+package bostree
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSortedSet(t *testing.T) {
+	zs := NewSortedSet()
+
+	added, err := zs.ZAdd(0,
+		ZMember{Member: "c", Score: 3},
+		ZMember{Member: "a", Score: 1},
+		ZMember{Member: "b", Score: 2},
+		ZMember{Member: "d", Score: 3},
+	)
+
+	t.Run("ZAdd", func(t *testing.T) {
+		if err != nil || added != 4 {
+			t.Errorf("Expected 4, but got %d (%v)\n", added, err)
+		}
+		if zs.ZCard() != 4 {
+			t.Errorf("Expected 4, but got %d\n", zs.ZCard())
+		}
+	})
+
+	t.Run("ZAdd Flags", func(t *testing.T) {
+		if _, err := zs.ZAdd(ZAddNX|ZAddXX, ZMember{Member: "a", Score: 1}); err == nil {
+			t.Errorf("Expected error for NX|XX, but got nil\n")
+		}
+		if _, err := zs.ZAdd(ZAddNX|ZAddGT, ZMember{Member: "a", Score: 1}); err == nil {
+			t.Errorf("Expected error for NX|GT, but got nil\n")
+		}
+		if n, _ := zs.ZAdd(ZAddXX, ZMember{Member: "z", Score: 1}); n != 0 || zs.ZCard() != 4 {
+			t.Errorf("Expected XX not to add, but got %d\n", n)
+		}
+		if n, _ := zs.ZAdd(ZAddNX, ZMember{Member: "a", Score: 10}); n != 0 {
+			t.Errorf("Expected NX not to update, but got %d\n", n)
+		}
+		if n, _ := zs.ZAdd(ZAddGT|ZAddCH, ZMember{Member: "a", Score: 0}); n != 0 {
+			t.Errorf("Expected GT not to lower the score, but got %d\n", n)
+		}
+		if n, _ := zs.ZAdd(ZAddLT|ZAddCH, ZMember{Member: "a", Score: 0.5}); n != 1 {
+			t.Errorf("Expected LT to lower the score, but got %d\n", n)
+		}
+		if score, _ := zs.ZScore("a"); score != 0.5 {
+			t.Errorf("Expected 0.5, but got %f\n", score)
+		}
+	})
+
+	t.Run("ZRank", func(t *testing.T) {
+		for i, member := range []string{"a", "b", "c", "d"} {
+			if rank, ok := zs.ZRank(member); !ok || rank != uint64(i) {
+				t.Errorf("Expected %d, but got %d\n", i, rank)
+			}
+			if rank, ok := zs.ZRevRank(member); !ok || rank != uint64(3-i) {
+				t.Errorf("Expected %d, but got %d\n", 3-i, rank)
+			}
+		}
+		if _, ok := zs.ZRank("missing"); ok {
+			t.Errorf("Expected missing member, but got a rank\n")
+		}
+	})
+
+	t.Run("ZRange", func(t *testing.T) {
+		if got := members(zs.ZRange(1, -1)); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+			t.Errorf("Expected [b c d], but got %v\n", got)
+		}
+		if got := members(zs.ZRevRange(0, 1)); !reflect.DeepEqual(got, []string{"d", "c"}) {
+			t.Errorf("Expected [d c], but got %v\n", got)
+		}
+		if got := zs.ZRange(5, 10); len(got) != 0 {
+			t.Errorf("Expected empty range, but got %v\n", got)
+		}
+	})
+
+	t.Run("ZRangeByScore", func(t *testing.T) {
+		var (
+			min = ScoreBound{Value: 0.5, Exclusive: true}
+			max = ScoreBound{Value: 3}
+		)
+		if got := members(zs.ZRangeByScore(min, max, 0, -1)); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+			t.Errorf("Expected [b c d], but got %v\n", got)
+		}
+		if got := members(zs.ZRangeByScore(min, max, 1, 1)); !reflect.DeepEqual(got, []string{"c"}) {
+			t.Errorf("Expected [c], but got %v\n", got)
+		}
+		if got := members(zs.ZRevRangeByScore(min, max, 0, -1)); !reflect.DeepEqual(got, []string{"d", "c", "b"}) {
+			t.Errorf("Expected [d c b], but got %v\n", got)
+		}
+		if got := members(zs.ZRevRangeByScore(min, max, 1, 1)); !reflect.DeepEqual(got, []string{"c"}) {
+			t.Errorf("Expected [c], but got %v\n", got)
+		}
+		if got := zs.ZRevRangeByScore(min, max, 3, 1); len(got) != 0 {
+			t.Errorf("Expected empty range, but got %v\n", got)
+		}
+		if n := zs.ZCount(ScoreBound{Value: 2}, ScoreBound{Value: 3, Exclusive: true}); n != 1 {
+			t.Errorf("Expected 1, but got %d\n", n)
+		}
+		if n := zs.ZCount(ScoreBound{Value: 4}, ScoreBound{Value: 1}); n != 0 {
+			t.Errorf("Expected 0, but got %d\n", n)
+		}
+	})
+
+	t.Run("ZIncrBy", func(t *testing.T) {
+		if score, err := zs.ZIncrBy(10, "a"); err != nil || score != 10.5 {
+			t.Errorf("Expected 10.5, but got %f (%v)\n", score, err)
+		}
+		if rank, _ := zs.ZRank("a"); rank != 3 {
+			t.Errorf("Expected 3, but got %d\n", rank)
+		}
+	})
+
+	t.Run("ZRem", func(t *testing.T) {
+		if n := zs.ZRem("b", "missing"); n != 1 {
+			t.Errorf("Expected 1, but got %d\n", n)
+		}
+		if n := zs.ZRemRangeByRank(0, 1); n != 2 {
+			t.Errorf("Expected 2, but got %d\n", n)
+		}
+		if got := members(zs.ZRange(0, -1)); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("Expected [a], but got %v\n", got)
+		}
+	})
+
+	t.Run("Many Members", func(t *testing.T) {
+		zs := NewSortedSet()
+		for i := 0; i < 1000; i++ {
+			zs.ZAdd(0, ZMember{Member: fmt.Sprintf("m%d", i), Score: float64(i % 10)})
+		}
+		zs.ZRemRangeByRank(0, 99)
+		if n := zs.ZCount(ScoreBound{Value: 1}, ScoreBound{Value: 1}); n != 100 {
+			t.Errorf("Expected 100, but got %d\n", n)
+		}
+		if n := zs.ZCount(ScoreBound{Value: 0}, ScoreBound{Value: 0}); n != 0 {
+			t.Errorf("Expected 0, but got %d\n", n)
+		}
+	})
+}
+
+func members(zms []ZMember) []string {
+	var result []string
+	for _, zm := range zms {
+		result = append(result, zm.Member)
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"math"
+
+	"github.com/bostree"
+	"github.com/bostree/typed_tree"
+)
+
+func float64Cmp(k1, k2 interface{}) int {
+	return typed_tree.CompareFloat64(k1.(float64), k2.(float64))
+}
+
+func buildTree(xs []float64) *bostree.BOSTree {
+	var tree = bostree.Build(float64Cmp)
+	for _, x := range xs {
+		tree.Insert(x, nil)
+	}
+	return tree
+}
+
+// midRank returns the 1-based rank of key in tree, with ties getting the
+// average of their positions.
+func midRank(tree *bostree.BOSTree, key interface{}) float64 {
+	return float64(tree.CountLess(key)+tree.CountLessOrEqual(key)+1) / 2
+}
+
+// Spearman returns Spearman's rank correlation of the paired samples x and
+// y: the Pearson correlation of their mid-ranks. It returns NaN if the
+// lengths differ, there are fewer than two pairs, or either sample is
+// constant.
+func Spearman(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return math.NaN()
+	}
+	var (
+		xTree, yTree  = buildTree(x), buildTree(y)
+		n             = float64(len(x))
+		mean          = (n + 1) / 2
+		sxy, sxx, syy float64
+	)
+	for i := range x {
+		dx := midRank(xTree, x[i]) - mean
+		dy := midRank(yTree, y[i]) - mean
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// KendallTau returns Kendall's tau-b of the paired samples x and y, which
+// accounts for ties in either sample. Pairs are counted in O(n log n) by
+// sweeping x in order and ranking each y among the ys of smaller x. It
+// returns NaN if the lengths differ or either sample is constant.
+func KendallTau(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return math.NaN()
+	}
+	// Sort the pairs by x through a tree keyed by x.
+	var byX = bostree.Build(float64Cmp)
+	for i := range x {
+		byX.Insert(x[i], y[i])
+	}
+
+	var (
+		seen                   = bostree.Build(float64Cmp)
+		concordant, discordant float64
+		xTies, yTies           float64
+	)
+	for node := byX.Select(0); node != nil; {
+		// Rank the whole group of equal x against the ys seen so far, then
+		// add it, so pairs tied in x count as neither.
+		var (
+			group = []interface{}{}
+			x     = node.Key
+		)
+		for ; node != nil && float64Cmp(node.Key, x) == 0; node = byX.NxtNode(node) {
+			group = append(group, node.Val)
+		}
+		for _, y := range group {
+			less, lessOrEqual := seen.CountLess(y), seen.CountLessOrEqual(y)
+			concordant += float64(less)
+			discordant += float64(seen.NodeCount() - lessOrEqual)
+		}
+		for _, y := range group {
+			seen.Insert(y, nil)
+		}
+		t := float64(len(group))
+		xTies += t * (t - 1) / 2
+	}
+	for node := seen.Select(0); node != nil; node = seen.UpperBound(node.Key) {
+		t := float64(seen.CountLessOrEqual(node.Key) - seen.CountLess(node.Key))
+		yTies += t * (t - 1) / 2
+	}
+
+	var n0 = float64(len(x)) * float64(len(x)-1) / 2
+	return (concordant - discordant) / math.Sqrt((n0-xTies)*(n0-yTies))
+}
+
+// MannWhitney is the result of a Mann-Whitney U test.
+type MannWhitney struct {
+	// U is the number of pairs (a, b) with a > b, ties counting half.
+	U float64
+	// Z is the normal approximation of U with tie and continuity
+	// correction, and P its two-sided p-value.
+	Z, P float64
+}
+
+// MannWhitneyU tests whether the keys of a tend to differ from those of b.
+// The trees may hold keys of any type in the same order; U is summed from
+// the ranks of a's keys in b, in O(n log n) without merging the samples.
+func MannWhitneyU(a, b *bostree.BOSTree) MannWhitney {
+	var (
+		n1, n2 = float64(a.NodeCount()), float64(b.NodeCount())
+		n      = n1 + n2
+		u      float64
+		// ties is the sum of t^3 - t over the groups of equal keys.
+		ties float64
+	)
+	if n1 == 0 || n2 == 0 {
+		return MannWhitney{Z: math.NaN(), P: math.NaN()}
+	}
+	for node := a.Select(0); node != nil; node = a.NxtNode(node) {
+		u += float64(b.CountLess(node.Key)+b.CountLessOrEqual(node.Key)) / 2
+	}
+	for _, pair := range [][2]*bostree.BOSTree{{a, b}, {b, a}} {
+		tree, other := pair[0], pair[1]
+		for node := tree.Select(0); node != nil; node = tree.UpperBound(node.Key) {
+			var (
+				inTree  = tree.CountLessOrEqual(node.Key) - tree.CountLess(node.Key)
+				inOther = other.CountLessOrEqual(node.Key) - other.CountLess(node.Key)
+			)
+			if tree == b && inOther > 0 {
+				// Counted from a already.
+				continue
+			}
+			t := float64(inTree + inOther)
+			ties += t*t*t - t
+		}
+	}
+
+	var (
+		mean  = n1 * n2 / 2
+		sigma = math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+		diff  = math.Max(math.Abs(u-mean)-0.5, 0)
+		z     = diff / sigma
+	)
+	if u < mean {
+		z = -z
+	}
+	return MannWhitney{U: u, Z: z, P: math.Erfc(math.Abs(z) / math.Sqrt2)}
+}
+
+// MannWhitneyUSamples runs MannWhitneyU on two samples.
+func MannWhitneyUSamples(a, b []float64) MannWhitney {
+	return MannWhitneyU(buildTree(a), buildTree(b))
+}
@@ -0,0 +1,165 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// The references rank by counting over all pairs.
+
+func refMidRanks(xs []float64) []float64 {
+	var ranks = make([]float64, len(xs))
+	for i, x := range xs {
+		var less, equal float64
+		for _, y := range xs {
+			if y < x {
+				less++
+			} else if y == x {
+				equal++
+			}
+		}
+		ranks[i] = less + (equal+1)/2
+	}
+	return ranks
+}
+
+func refPearson(xs, ys []float64) float64 {
+	var (
+		mx, my        = refMean(xs), refMean(ys)
+		sxy, sxx, syy float64
+	)
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+		syy += (ys[i] - my) * (ys[i] - my)
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+func refKendallTau(xs, ys []float64) float64 {
+	var c, d, tx, ty, n0 float64
+	for i := range xs {
+		for j := i + 1; j < len(xs); j++ {
+			n0++
+			var s = (xs[i] - xs[j]) * (ys[i] - ys[j])
+			switch {
+			case s > 0:
+				c++
+			case s < 0:
+				d++
+			}
+			if xs[i] == xs[j] {
+				tx++
+			}
+			if ys[i] == ys[j] {
+				ty++
+			}
+		}
+	}
+	return (c - d) / math.Sqrt((n0-tx)*(n0-ty))
+}
+
+func refMannWhitneyU(a, b []float64) float64 {
+	var u float64
+	for _, x := range a {
+		for _, y := range b {
+			if x > y {
+				u++
+			} else if x == y {
+				u += 0.5
+			}
+		}
+	}
+	return u
+}
+
+// randomPairs returns correlated samples with many ties.
+func randomPairs(rng *rand.Rand, n int) ([]float64, []float64) {
+	var xs, ys = make([]float64, n), make([]float64, n)
+	for i := range xs {
+		xs[i] = float64(rng.Intn(n / 2))
+		ys[i] = math.Floor(xs[i]/2) + float64(rng.Intn(5))
+	}
+	return xs, ys
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestSpearmanAndKendallTau(t *testing.T) {
+	var rng = rand.New(rand.NewSource(9))
+	for _, n := range []int{2, 10, 101, 400} {
+		var xs, ys = randomPairs(rng, n)
+		if n == 2 {
+			xs, ys = []float64{1, 2}, []float64{5, 3}
+		}
+		if got, want := Spearman(xs, ys), refPearson(refMidRanks(xs), refMidRanks(ys)); !closeTo(got, want) {
+			t.Errorf("n=%d: Spearman = %v, want %v", n, got, want)
+		}
+		if got, want := KendallTau(xs, ys), refKendallTau(xs, ys); !closeTo(got, want) {
+			t.Errorf("n=%d: KendallTau = %v, want %v", n, got, want)
+		}
+	}
+
+	var xs = []float64{1, 2, 3, 4, 5}
+	if got := Spearman(xs, []float64{10, 20, 30, 40, 50}); !closeTo(got, 1) {
+		t.Errorf("Spearman of a monotone pair = %v, want 1", got)
+	}
+	if got := KendallTau(xs, []float64{5, 4, 3, 2, 1}); !closeTo(got, -1) {
+		t.Errorf("KendallTau of a reversed pair = %v, want -1", got)
+	}
+	for _, got := range []float64{
+		Spearman(xs, xs[:4]),
+		KendallTau([]float64{1}, []float64{1}),
+		KendallTau(xs, []float64{7, 7, 7, 7, 7}),
+	} {
+		if !math.IsNaN(got) {
+			t.Errorf("got %v, want NaN", got)
+		}
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	// Matches scipy.stats.mannwhitneyu(a, b, method="asymptotic").
+	var result = MannWhitneyUSamples([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	if result.U != 0 || math.Abs(result.Z+2.5067) > 1e-4 || math.Abs(result.P-0.012186) > 1e-5 {
+		t.Errorf("separated samples: got %+v", result)
+	}
+
+	var rng = rand.New(rand.NewSource(10))
+	for _, n := range []int{1, 7, 60} {
+		var a, b = make([]float64, n), make([]float64, 2*n+1)
+		for i := range a {
+			a[i] = float64(rng.Intn(10))
+		}
+		for i := range b {
+			b[i] = float64(rng.Intn(12))
+		}
+		result := MannWhitneyUSamples(a, b)
+		if want := refMannWhitneyU(a, b); result.U != want {
+			t.Errorf("n=%d: U = %v, want %v", n, result.U, want)
+		}
+
+		// The tie-corrected variance is that of the mid-ranks of a.
+		var (
+			all    = append(append([]float64{}, a...), b...)
+			ranks  = refMidRanks(all)
+			nn     = float64(len(all))
+			mean   = (nn + 1) / 2
+			sumSq  float64
+			n1, n2 = float64(len(a)), float64(len(b))
+		)
+		for _, r := range ranks {
+			sumSq += (r - mean) * (r - mean)
+		}
+		var (
+			sigma = math.Sqrt(n1 * n2 * sumSq / (nn * (nn - 1)))
+			diff  = math.Max(math.Abs(result.U-n1*n2/2)-0.5, 0)
+		)
+		if got := math.Abs(result.Z); !closeTo(got, diff/sigma) {
+			t.Errorf("n=%d: |Z| = %v, want %v", n, got, diff/sigma)
+		}
+	}
+}
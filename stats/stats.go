@@ -0,0 +1,219 @@
+// Package stats computes robust statistics from the order statistics of a
+// tree of float64 keys.
+//
+// Every statistic reads a handful of keys by position. Sums over position
+// ranges take O(log n) on a bostree.StatsTree and O(k) for k keys on a plain
+// BOSTree, so TrimmedMean and WinsorizedMean are O(log n) on the former.
+// MAD is O(log^2 n) on both.
+//
+// Spearman, KendallTau and MannWhitneyU are rank statistics: they rank keys
+// with CountLess and CountLessOrEqual, so ties get mid-ranks, and take
+// O(n log n).
+package stats
+
+import (
+	"math"
+
+	"github.com/bostree"
+)
+
+// Data is a sorted float64 sample with access by position.
+type Data interface {
+	Len() uint64
+	// At returns the key at position i.
+	At(i uint64) float64
+	// Sum returns the sum of the keys at positions [from, to).
+	Sum(from, to uint64) float64
+}
+
+type treeData struct {
+	tree *bostree.BOSTree
+}
+
+// FromTree reads a BOSTree whose keys are float64 in ascending order.
+func FromTree(tree *bostree.BOSTree) Data {
+	return treeData{tree}
+}
+
+func (d treeData) Len() uint64 {
+	return d.tree.NodeCount()
+}
+
+func (d treeData) At(i uint64) float64 {
+	return d.tree.Select(i).Key.(float64)
+}
+
+func (d treeData) Sum(from, to uint64) float64 {
+	var sum float64
+	if from < to {
+		d.tree.Ascend(from, func(key, val interface{}) bool {
+			sum += key.(float64)
+			from++
+			return from < to
+		})
+	}
+	return sum
+}
+
+type statsData struct {
+	tree *bostree.StatsTree
+}
+
+// FromStatsTree reads a StatsTree, whose subtree moments make Sum O(log n).
+func FromStatsTree(tree *bostree.StatsTree) Data {
+	return statsData{tree}
+}
+
+func (d statsData) Len() uint64 {
+	return d.tree.Tree.NodeCount()
+}
+
+func (d statsData) At(i uint64) float64 {
+	return d.tree.Tree.Select(i).Key.(float64)
+}
+
+func (d statsData) Sum(from, to uint64) float64 {
+	var s = d.tree.StatsByRank(from, to)
+	return s.Mean * float64(s.Count)
+}
+
+// Quantile returns the q-quantile interpolated linearly between the closest
+// positions, like R's type 7 and NumPy's default. It returns NaN for empty
+// data or q outside [0, 1].
+func Quantile(d Data, q float64) float64 {
+	var n = d.Len()
+	if n == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+	var (
+		h    = q * float64(n-1)
+		i    = uint64(h)
+		x    = d.At(i)
+		frac = h - float64(i)
+	)
+	if frac == 0 {
+		return x
+	}
+	return x + frac*(d.At(i+1)-x)
+}
+
+func Median(d Data) float64 {
+	return Quantile(d, 0.5)
+}
+
+// IQR returns the interquartile range, Q3 - Q1.
+func IQR(d Data) float64 {
+	return Quantile(d, 0.75) - Quantile(d, 0.25)
+}
+
+// trimCount returns how many keys alpha cuts off each end of n keys.
+func trimCount(n uint64, alpha float64) (uint64, bool) {
+	if n == 0 || !(alpha >= 0 && alpha < 0.5) {
+		return 0, false
+	}
+	return uint64(alpha * float64(n)), true
+}
+
+// TrimmedMean returns the mean without the floor(alpha*n) smallest and
+// largest keys. alpha must be in [0, 0.5); NaN is returned otherwise or for
+// empty data.
+func TrimmedMean(d Data, alpha float64) float64 {
+	var n = d.Len()
+	k, ok := trimCount(n, alpha)
+	if !ok {
+		return math.NaN()
+	}
+	return d.Sum(k, n-k) / float64(n-2*k)
+}
+
+// WinsorizedMean returns the mean with the floor(alpha*n) smallest keys
+// raised to the next one and as many largest keys lowered to the one before
+// them. alpha must be in [0, 0.5).
+func WinsorizedMean(d Data, alpha float64) float64 {
+	var n = d.Len()
+	k, ok := trimCount(n, alpha)
+	if !ok {
+		return math.NaN()
+	}
+	var sum = d.Sum(k, n-k)
+	if k > 0 {
+		sum += float64(k) * (d.At(k) + d.At(n-k-1))
+	}
+	return sum / float64(n)
+}
+
+// MAD returns the median absolute deviation from the median, unscaled;
+// multiply by 1.4826 to estimate the standard deviation of normal data.
+func MAD(d Data) float64 {
+	var n = d.Len()
+	if n == 0 {
+		return math.NaN()
+	}
+	var (
+		median = Median(d)
+		// Keys before split are below the median, the others at or above
+		// it.
+		split = lowerBound(d, median)
+		// below(i) and above(j) are the i-th smallest deviations of the keys
+		// below the median and of those at or above it; both grow with
+		// their index.
+		below = func(i uint64) float64 { return median - d.At(split-1-i) }
+		above = func(j uint64) float64 { return d.At(split+j) - median }
+	)
+	var (
+		lo = kthOfTwo(below, split, above, n-split, (n-1)/2)
+		hi = lo
+	)
+	if n%2 == 0 {
+		hi = kthOfTwo(below, split, above, n-split, n/2)
+	}
+	return (lo + hi) / 2
+}
+
+// lowerBound returns the number of keys less than x.
+func lowerBound(d Data, x float64) uint64 {
+	var lo, hi uint64 = 0, d.Len()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if d.At(mid) < x {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// kthOfTwo returns the k-th smallest (0-based) element of two ascending
+// sequences a and b of lengths na and nb, in O(log(na+nb)) reads of each.
+func kthOfTwo(a func(uint64) float64, na uint64, b func(uint64) float64, nb uint64, k uint64) float64 {
+	// The k+1 smallest elements are the first i of a and the first k+1-i of
+	// b, for the largest feasible i with a[i-1] <= b[k+1-i].
+	var lo, hi uint64 = 0, k + 1
+	if hi > na {
+		hi = na
+	}
+	if k+1 > nb {
+		lo = k + 1 - nb
+	}
+	for lo < hi {
+		i := lo + (hi-lo+1)/2
+		if j := k + 1 - i; j == nb || a(i-1) <= b(j) {
+			lo = i
+		} else {
+			hi = i - 1
+		}
+	}
+
+	var (
+		i, j   = lo, k + 1 - lo
+		result = math.Inf(-1)
+	)
+	if i > 0 {
+		result = a(i - 1)
+	}
+	if j > 0 && b(j-1) > result {
+		result = b(j - 1)
+	}
+	return result
+}
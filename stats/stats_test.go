@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/bostree"
+	"github.com/bostree/typed_tree"
+)
+
+// The reference implementations work on sorted slices the textbook way.
+
+func refQuantile(xs []float64, q float64) float64 {
+	h := q * float64(len(xs)-1)
+	lo := math.Floor(h)
+	if int(lo)+1 >= len(xs) {
+		return xs[int(lo)]
+	}
+	return xs[int(lo)] + (h-lo)*(xs[int(lo)+1]-xs[int(lo)])
+}
+
+func refMean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func refTrimmedMean(xs []float64, alpha float64) float64 {
+	k := int(alpha * float64(len(xs)))
+	return refMean(xs[k : len(xs)-k])
+}
+
+func refWinsorizedMean(xs []float64, alpha float64) float64 {
+	var (
+		k = int(alpha * float64(len(xs)))
+		w = append([]float64(nil), xs...)
+	)
+	for i := 0; i < k; i++ {
+		w[i], w[len(w)-1-i] = xs[k], xs[len(xs)-1-k]
+	}
+	return refMean(w)
+}
+
+func refMAD(xs []float64) float64 {
+	var (
+		median = refQuantile(xs, 0.5)
+		dev    = make([]float64, len(xs))
+	)
+	for i, x := range xs {
+		dev[i] = math.Abs(x - median)
+	}
+	sort.Float64s(dev)
+	return refQuantile(dev, 0.5)
+}
+
+func TestStats(t *testing.T) {
+	var (
+		r     = rand.New(rand.NewSource(6))
+		close = func(a, b float64) bool {
+			return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+		}
+	)
+	for round := 0; round < 50; round++ {
+		var (
+			n     = 1 + r.Intn(200)
+			xs    = make([]float64, n)
+			tree  = bostree.Build(func(k1, k2 interface{}) int { return typed_tree.CompareFloat64(k1.(float64), k2.(float64)) })
+			stree = bostree.NewStatsTree()
+		)
+		for i := range xs {
+			// Small integers give plenty of ties.
+			xs[i] = float64(r.Intn(50)) + math.Floor(r.ExpFloat64()*3)
+			tree.Insert(xs[i], nil)
+			stree.Insert(xs[i], nil)
+		}
+		sort.Float64s(xs)
+
+		for name, d := range map[string]Data{"Tree": FromTree(tree), "StatsTree": FromStatsTree(stree)} {
+			for _, q := range []float64{0, 0.1, 0.25, 0.5, 0.9, 1} {
+				if got, expected := Quantile(d, q), refQuantile(xs, q); !close(got, expected) {
+					t.Fatalf("%s, n=%d: Expected Quantile(%f) = %f, but got %f\n", name, n, q, expected, got)
+				}
+			}
+			if got, expected := IQR(d), refQuantile(xs, 0.75)-refQuantile(xs, 0.25); !close(got, expected) {
+				t.Fatalf("%s, n=%d: Expected IQR %f, but got %f\n", name, n, expected, got)
+			}
+			for _, alpha := range []float64{0, 0.05, 0.1, 0.25, 0.49} {
+				if got, expected := TrimmedMean(d, alpha), refTrimmedMean(xs, alpha); !close(got, expected) {
+					t.Fatalf("%s, n=%d: Expected TrimmedMean(%f) = %f, but got %f\n", name, n, alpha, expected, got)
+				}
+				if got, expected := WinsorizedMean(d, alpha), refWinsorizedMean(xs, alpha); !close(got, expected) {
+					t.Fatalf("%s, n=%d: Expected WinsorizedMean(%f) = %f, but got %f\n", name, n, alpha, expected, got)
+				}
+			}
+			if got, expected := MAD(d), refMAD(xs); !close(got, expected) {
+				t.Fatalf("%s, n=%d: Expected MAD %f, but got %f (%v)\n", name, n, expected, got, xs)
+			}
+		}
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		var d = FromStatsTree(bostree.NewStatsTree())
+		for _, v := range []float64{Median(d), IQR(d), TrimmedMean(d, 0.1), MAD(d)} {
+			if !math.IsNaN(v) {
+				t.Errorf("Expected NaN for empty data, but got %f\n", v)
+			}
+		}
+		var stree = bostree.NewStatsTree()
+		stree.Insert(1, nil)
+		if v := Quantile(FromStatsTree(stree), 1.5); !math.IsNaN(v) {
+			t.Errorf("Expected NaN for q > 1, but got %f\n", v)
+		}
+	})
+}
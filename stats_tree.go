@@ -0,0 +1,156 @@
+package bostree
+
+import (
+	"math"
+
+	. "github.com/bostree/bos_node"
+	"github.com/bostree/typed_tree"
+)
+
+// Summary describes the keys of a range. Var is the population variance.
+type Summary struct {
+	Count     uint64
+	Mean, Var float64
+	Min, Max  float64
+}
+
+func (s Summary) StdDev() float64 {
+	return math.Sqrt(s.Var)
+}
+
+// moments holds count, mean and sum of squared deviations, which merge
+// without the cancellation of plain sums of squares (Chan et al.).
+type moments struct {
+	count    uint64
+	mean, m2 float64
+	min, max float64
+}
+
+func singleMoments(x float64) moments {
+	return moments{count: 1, mean: x, min: x, max: x}
+}
+
+func (a moments) merge(b moments) moments {
+	if a.count == 0 {
+		return b
+	}
+	if b.count == 0 {
+		return a
+	}
+	var (
+		n     = float64(a.count + b.count)
+		delta = b.mean - a.mean
+		m     = moments{
+			count: a.count + b.count,
+			mean:  a.mean + delta*float64(b.count)/n,
+			m2:    a.m2 + b.m2 + delta*delta*float64(a.count)*float64(b.count)/n,
+			min:   math.Min(a.min, b.min),
+			max:   math.Max(a.max, b.max),
+		}
+	)
+	return m
+}
+
+func (a moments) summary() Summary {
+	if a.count == 0 {
+		return Summary{}
+	}
+	return Summary{
+		Count: a.count,
+		Mean:  a.mean,
+		Var:   a.m2 / float64(a.count),
+		Min:   a.min,
+		Max:   a.max,
+	}
+}
+
+// statsEntry is the Val of a StatsTree node.
+type statsEntry struct {
+	val     interface{}
+	moments moments
+}
+
+// StatsTree keeps float64 keys with the moments of every subtree, so the
+// mean, variance, minimum and maximum of any key or rank range take
+// O(log n). Keys are ordered by typed_tree.CompareFloat64.
+type StatsTree struct {
+	// Tree holds the keys with *statsEntry values. Its rank and quantile
+	// methods can be used directly, but it must only be changed through the
+	// StatsTree.
+	Tree *BOSTree
+}
+
+func NewStatsTree() *StatsTree {
+	var st = &StatsTree{Tree: Build(func(k1, k2 interface{}) int {
+		return typed_tree.CompareFloat64(k1.(float64), k2.(float64))
+	})}
+	st.Tree.Augment = augmentStats
+	return st
+}
+
+func augmentStats(node *BOSNode) {
+	var entry = node.Val.(*statsEntry)
+	entry.moments = subtreeMoments(node.LeftChildNode).
+		merge(singleMoments(node.Key.(float64))).
+		merge(subtreeMoments(node.RightChildNode))
+}
+
+func subtreeMoments(node *BOSNode) moments {
+	if node == nil {
+		return moments{}
+	}
+	return node.Val.(*statsEntry).moments
+}
+
+func (st *StatsTree) Insert(key float64, val interface{}) *BOSNode {
+	return st.Tree.Insert(key, &statsEntry{val: val})
+}
+
+func (st *StatsTree) Remove(node *BOSNode) {
+	st.Tree.Remove(node)
+}
+
+// Value returns the value stored with a node of Tree.
+func (st *StatsTree) Value(node *BOSNode) interface{} {
+	return node.Val.(*statsEntry).val
+}
+
+// Stats summarizes the keys in [lo, hi].
+func (st *StatsTree) Stats(lo, hi float64) Summary {
+	return st.StatsByRank(st.Tree.CountLess(lo), st.Tree.CountLessOrEqual(hi))
+}
+
+// StatsByRank summarizes the keys at positions [from, to).
+func (st *StatsTree) StatsByRank(from, to uint64) Summary {
+	return rangeMoments(st.Tree.RootNode, from, to).summary()
+}
+
+// rangeMoments merges the moments of positions [from, to) of the subtree at
+// node. Only the nodes on the paths to both ends are visited; subtrees in
+// between are taken whole.
+func rangeMoments(node *BOSNode, from, to uint64) moments {
+	if node == nil || from >= to {
+		return moments{}
+	}
+	if from == 0 && to >= uint64(node.Size) {
+		return subtreeMoments(node)
+	}
+	var (
+		left = node.LeftChildCount()
+		m    moments
+	)
+	if from < left {
+		m = rangeMoments(node.LeftChildNode, from, to)
+	}
+	if from <= left && left < to {
+		m = m.merge(singleMoments(node.Key.(float64)))
+	}
+	if to > left+1 {
+		var rightFrom uint64
+		if from > left+1 {
+			rightFrom = from - left - 1
+		}
+		m = m.merge(rangeMoments(node.RightChildNode, rightFrom, to-left-1))
+	}
+	return m
+}
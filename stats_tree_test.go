@@ -0,0 +1,64 @@
+package bostree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestStatsTree(t *testing.T) {
+	var (
+		st    = NewStatsTree()
+		keys  []float64
+		r     = rand.New(rand.NewSource(8))
+		close = func(a, b float64) bool {
+			return math.Abs(a-b) <= 1e-6*math.Max(1, math.Abs(b))
+		}
+	)
+	for i := 0; i < 3000; i++ {
+		// A large offset makes naive sums of squares lose all precision.
+		key := 1e9 + r.NormFloat64()*10
+		st.Insert(key, i)
+	}
+	for i := 0; i < 1000; i++ {
+		st.Remove(st.Tree.Select(uint64(r.Intn(int(st.Tree.NodeCount())))))
+	}
+	for node := st.Tree.Select(0); node != nil; node = st.Tree.NxtNode(node) {
+		keys = append(keys, node.Key.(float64))
+	}
+
+	for q := 0; q < 300; q++ {
+		var (
+			from = uint64(r.Intn(len(keys) + 1))
+			to   = uint64(r.Intn(len(keys) + 1))
+			s    = st.StatsByRank(from, to)
+		)
+		if from >= to {
+			if s != (Summary{}) {
+				t.Fatalf("Expected an empty summary, but got %v\n", s)
+			}
+			continue
+		}
+		var mean, variance float64
+		for _, key := range keys[from:to] {
+			mean += key
+		}
+		mean /= float64(to - from)
+		for _, key := range keys[from:to] {
+			variance += (key - mean) * (key - mean)
+		}
+		variance /= float64(to - from)
+
+		if s.Count != to-from || !close(s.Mean, mean) || !close(s.Var, variance) || s.Min != keys[from] || s.Max != keys[to-1] {
+			t.Fatalf("[%d, %d): Expected %d/%f/%f/%f/%f, but got %v\n", from, to, to-from, mean, variance, keys[from], keys[to-1], s)
+		}
+	}
+
+	var s = st.Stats(keys[10], keys[20])
+	if s.Count != 11 || s.Min != keys[10] || s.Max != keys[20] {
+		t.Errorf("Expected 11 keys from %f to %f, but got %v\n", keys[10], keys[20], s)
+	}
+	if whole := st.Stats(math.Inf(-1), math.Inf(1)); whole.Count != uint64(len(keys)) || !close(whole.StdDev(), math.Sqrt(whole.Var)) {
+		t.Errorf("Expected all %d keys, but got %v\n", len(keys), whole)
+	}
+}
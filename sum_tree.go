@@ -0,0 +1,107 @@
+package bostree
+
+import (
+	. "github.com/bostree/bos_node"
+)
+
+// sumEntry is the Val of a SumTree node.
+type sumEntry struct {
+	value float64
+	// sum is the total of the values in the node's subtree.
+	sum float64
+}
+
+// SumTree keeps a numeric value per key and the sum of every subtree, so
+// sums over rank or key ranges take O(log n).
+type SumTree struct {
+	// Tree holds the keys with *sumEntry values. Its rank methods can be
+	// used directly, but it must only be changed through the SumTree.
+	Tree *BOSTree
+}
+
+func NewSumTree(cmp_func func(k1, k2 interface{}) int) *SumTree {
+	var st = &SumTree{Tree: Build(cmp_func)}
+	st.Tree.Augment = augmentSum
+	return st
+}
+
+func augmentSum(node *BOSNode) {
+	var entry = node.Val.(*sumEntry)
+	entry.sum = entry.value + subtreeSum(node.LeftChildNode) + subtreeSum(node.RightChildNode)
+}
+
+func subtreeSum(node *BOSNode) float64 {
+	if node == nil {
+		return 0
+	}
+	return node.Val.(*sumEntry).sum
+}
+
+func (st *SumTree) Insert(key interface{}, value float64) *BOSNode {
+	return st.Tree.Insert(key, &sumEntry{value: value, sum: value})
+}
+
+func (st *SumTree) Remove(node *BOSNode) {
+	st.Tree.Remove(node)
+}
+
+// Value returns the value of a node of Tree.
+func (st *SumTree) Value(node *BOSNode) float64 {
+	return node.Val.(*sumEntry).value
+}
+
+// Total returns the sum of all values.
+func (st *SumTree) Total() float64 {
+	return subtreeSum(st.Tree.RootNode)
+}
+
+// PrefixSum returns the sum of the values at positions below rank.
+func (st *SumTree) PrefixSum(rank uint64) float64 {
+	var (
+		node = st.Tree.RootNode
+		sum  float64
+	)
+	for node != nil {
+		if leftCount := node.LeftChildCount(); rank <= leftCount {
+			node = node.LeftChildNode
+		} else {
+			sum += subtreeSum(node.LeftChildNode) + st.Value(node)
+			rank -= leftCount + 1
+			node = node.RightChildNode
+		}
+	}
+	return sum
+}
+
+// RangeSum returns the sum of the values of the keys in [lo, hi].
+func (st *SumTree) RangeSum(lo, hi interface{}) float64 {
+	var (
+		from = st.Tree.CountLess(lo)
+		to   = st.Tree.CountLessOrEqual(hi)
+	)
+	if to <= from {
+		return 0
+	}
+	return st.PrefixSum(to) - st.PrefixSum(from)
+}
+
+// SelectBySum returns the first node at which the running sum of values
+// reaches target, or nil if the total stays below it. Values must not be
+// negative.
+func (st *SumTree) SelectBySum(target float64) *BOSNode {
+	var node = st.Tree.RootNode
+	for node != nil {
+		if leftSum := subtreeSum(node.LeftChildNode); node.HasLeftChild() && leftSum >= target {
+			node = node.LeftChildNode
+		} else {
+			target -= leftSum
+			var value = st.Value(node)
+			if value >= target {
+				return node
+			}
+			target -= value
+			node = node.RightChildNode
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package bostree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/bostree/bos_node"
+)
+
+func checkSums(t *testing.T, node *BOSNode) float64 {
+	if node == nil {
+		return 0
+	}
+	var expected = node.Val.(*sumEntry).value + checkSums(t, node.LeftChildNode) + checkSums(t, node.RightChildNode)
+	if sum := subtreeSum(node); math.Abs(sum-expected) > 1e-9 {
+		t.Fatalf("Expected subtree sum %f, but got %f\n", expected, sum)
+	}
+	return expected
+}
+
+func TestSumTree(t *testing.T) {
+	var (
+		st    = NewSumTree(func(k1, k2 interface{}) int { return k1.(int) - k2.(int) })
+		nodes []*BOSNode
+		r     = rand.New(rand.NewSource(4))
+	)
+	for op := 0; op < 5000; op++ {
+		if len(nodes) == 0 || r.Intn(3) > 0 {
+			nodes = append(nodes, st.Insert(r.Intn(1000), float64(r.Intn(100))))
+		} else {
+			i := r.Intn(len(nodes))
+			st.Remove(nodes[i])
+			nodes = append(nodes[:i], nodes[i+1:]...)
+		}
+	}
+	checkSums(t, st.Tree.RootNode)
+
+	var (
+		prefix = []float64{0}
+		keys   []int
+	)
+	for node := st.Tree.Select(0); node != nil; node = st.Tree.NxtNode(node) {
+		prefix = append(prefix, prefix[len(prefix)-1]+st.Value(node))
+		keys = append(keys, node.Key.(int))
+	}
+	for rank := range prefix {
+		if sum := st.PrefixSum(uint64(rank)); sum != prefix[rank] {
+			t.Fatalf("Expected PrefixSum(%d) = %f, but got %f\n", rank, prefix[rank], sum)
+		}
+	}
+	if st.Total() != prefix[len(prefix)-1] {
+		t.Errorf("Expected %f, but got %f\n", prefix[len(prefix)-1], st.Total())
+	}
+
+	for q := 0; q < 200; q++ {
+		var (
+			lo, hi   = r.Intn(1100) - 50, r.Intn(1100) - 50
+			expected float64
+		)
+		for i, key := range keys {
+			if lo <= key && key <= hi {
+				expected += prefix[i+1] - prefix[i]
+			}
+		}
+		if sum := st.RangeSum(lo, hi); sum != expected {
+			t.Fatalf("Expected RangeSum(%d, %d) = %f, but got %f\n", lo, hi, expected, sum)
+		}
+
+		target := r.Float64() * st.Total() * 1.1
+		node := st.SelectBySum(target)
+		i := 0
+		for i+1 < len(prefix) && prefix[i+1] < target {
+			i++
+		}
+		if i+1 == len(prefix) {
+			if node != nil {
+				t.Fatalf("Expected nil past the total %f, but got %v\n", st.Total(), node.Key)
+			}
+		} else if node == nil || st.Tree.Rank(node) != uint64(i) {
+			t.Fatalf("Expected SelectBySum(%f) at %d, but got %v\n", target, i, node)
+		}
+	}
+	if node := st.SelectBySum(0); node != st.Tree.Select(0) {
+		t.Errorf("Expected SelectBySum(0) to be the first node\n")
+	}
+}
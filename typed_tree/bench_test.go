@@ -0,0 +1,115 @@
+package typed_tree_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bostree"
+	"github.com/bostree/typed_tree"
+)
+
+// The benchmarks below follow the README table: margins are LookUps of the
+// smallest and largest key, ranks are Rank calls on nodes spread evenly over
+// the tree.
+
+const benchSize = 1000000
+
+var (
+	benchBOSTree *bostree.BOSTree
+	benchFloat64 *typed_tree.Float64Tree
+	benchInt64   *typed_tree.Int64Tree
+)
+
+func benchTrees() {
+	if benchBOSTree != nil {
+		return
+	}
+	benchBOSTree = bostree.Build(func(k1, k2 interface{}) int {
+		return typed_tree.CompareFloat64(k1.(float64), k2.(float64))
+	})
+	benchFloat64 = typed_tree.BuildFloat64Tree()
+	benchInt64 = typed_tree.BuildInt64Tree()
+	for i := 0; i < benchSize; i++ {
+		val := fmt.Sprintf("p%d", i)
+		benchBOSTree.Insert(float64(i), val)
+		benchFloat64.Insert(float64(i), val)
+		benchInt64.Insert(int64(i), val)
+	}
+}
+
+func BenchmarkFindMargin(b *testing.B) {
+	benchTrees()
+	for _, margin := range []struct {
+		name string
+		key  int
+	}{{"Left", 0}, {"Right", benchSize - 1}} {
+		b.Run("BOSTree/"+margin.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchBOSTree.LookUp(float64(margin.key))
+			}
+		})
+		b.Run("Float64Tree/"+margin.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchFloat64.LookUp(float64(margin.key))
+			}
+		})
+		b.Run("Int64Tree/"+margin.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchInt64.LookUp(int64(margin.key))
+			}
+		})
+	}
+}
+
+func BenchmarkFindRank(b *testing.B) {
+	benchTrees()
+	for _, rank := range []struct {
+		name string
+		key  int
+	}{{"Left", 0}, {"Right", benchSize - 1}, {"Average", -1}} {
+		key := func(i int) int {
+			if rank.key >= 0 {
+				return rank.key
+			}
+			return (i * 100003) % benchSize
+		}
+		b.Run("BOSTree/"+rank.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchBOSTree.Rank(benchBOSTree.Select(uint64(key(i))))
+			}
+		})
+		b.Run("Float64Tree/"+rank.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchFloat64.Rank(benchFloat64.Select(uint64(key(i))))
+			}
+		})
+		b.Run("Int64Tree/"+rank.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchInt64.Rank(benchInt64.Select(uint64(key(i))))
+			}
+		})
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	b.Run("BOSTree", func(b *testing.B) {
+		tree := bostree.Build(func(k1, k2 interface{}) int {
+			return typed_tree.CompareFloat64(k1.(float64), k2.(float64))
+		})
+		for i := 0; i < b.N; i++ {
+			tree.Insert(float64((i*100003)%benchSize), nil)
+		}
+	})
+	b.Run("Float64Tree", func(b *testing.B) {
+		tree := typed_tree.BuildFloat64Tree()
+		for i := 0; i < b.N; i++ {
+			tree.Insert(float64((i*100003)%benchSize), nil)
+		}
+	})
+	b.Run("Int64Tree", func(b *testing.B) {
+		tree := typed_tree.BuildInt64Tree()
+		for i := 0; i < b.N; i++ {
+			tree.Insert(int64((i*100003)%benchSize), nil)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+//go:build ignore
+// +build ignore
+
+// gen.go writes one tree per key type from typed_tree.tmpl. Run it with
+// go generate.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"strings"
+	"text/template"
+)
+
+var types = []struct {
+	Name, Type, Cmp string
+}{
+	{"Float64", "float64", "CompareFloat64"},
+	{"Int64", "int64", "CompareInt64"},
+}
+
+func main() {
+	tmpl := template.Must(template.ParseFiles("typed_tree.tmpl"))
+	for _, t := range types {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, t); err != nil {
+			log.Fatal(err)
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(strings.ToLower(t.Name)+"_tree.go", src, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
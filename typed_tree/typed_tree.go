@@ -0,0 +1,45 @@
+// Package typed_tree has BOSTree variants for float64 and int64 keys. Keys
+// are stored inline and compared directly instead of through CmpFunc and a
+// type assertion on interface{} keys.
+//
+// The trees are generated from typed_tree.tmpl; edit the template and run
+// go generate instead of editing float64_tree.go or int64_tree.go.
+package typed_tree
+
+import (
+	"math"
+)
+
+//go:generate go run gen.go
+
+// CompareFloat64 orders floats by the IEEE 754 total order:
+// -NaN < -Inf < ... < -0 < +0 < ... < +Inf < +NaN. Unlike <, it is a strict
+// weak order even with NaNs, and it keeps -0 before +0.
+func CompareFloat64(f1, f2 float64) int {
+	// Ordinary comparisons agree with the total order; only equal floats,
+	// zeros and NaNs need a look at the bits.
+	if f1 < f2 {
+		return -1
+	}
+	if f1 > f2 {
+		return 1
+	}
+	return CompareInt64(float64TotalKey(f1), float64TotalKey(f2))
+}
+
+// float64TotalKey maps f to an int64 whose signed order is the total order:
+// negative floats have every bit but the sign flipped.
+func float64TotalKey(f float64) int64 {
+	var bits = int64(math.Float64bits(f))
+	return bits ^ int64(uint64(bits>>63)>>1)
+}
+
+func CompareInt64(i1, i2 int64) int {
+	if i1 < i2 {
+		return -1
+	}
+	if i1 > i2 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package typed_tree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func checkFloat64Node(t *testing.T, node *Float64Node) (uint32, uint8) {
+	if node == nil {
+		return 0, 0
+	}
+	var (
+		leftSize, leftDepth   = checkFloat64Node(t, node.LeftChildNode)
+		rightSize, rightDepth = checkFloat64Node(t, node.RightChildNode)
+	)
+	if node.Size != leftSize+rightSize+1 {
+		t.Fatalf("Expected size %d, but got %d\n", leftSize+rightSize+1, node.Size)
+	}
+	if balance := node.balance(); balance < -1 || balance > 1 {
+		t.Fatalf("Expected a balanced node, but got %d\n", balance)
+	}
+	if leftDepth > rightDepth {
+		return node.Size, leftDepth + 1
+	}
+	return node.Size, rightDepth + 1
+}
+
+func TestFloat64Tree(t *testing.T) {
+	var (
+		tree  = BuildFloat64Tree()
+		ref   []float64
+		nodes = map[float64][]*Float64Node{}
+		r     = rand.New(rand.NewSource(3))
+	)
+	for op := 0; op < 20000; op++ {
+		key := float64(r.Intn(500)) - 250
+		if r.Intn(3) > 0 || len(nodes[key]) == 0 {
+			nodes[key] = append(nodes[key], tree.Insert(key, op))
+			i := sort.SearchFloat64s(ref, key+1)
+			ref = append(ref, 0)
+			copy(ref[i+1:], ref[i:])
+			ref[i] = key
+		} else {
+			tree.Remove(nodes[key][0])
+			nodes[key] = nodes[key][1:]
+			i := sort.SearchFloat64s(ref, key)
+			ref = append(ref[:i], ref[i+1:]...)
+		}
+	}
+
+	checkFloat64Node(t, tree.RootNode)
+	if tree.NodeCount() != uint64(len(ref)) {
+		t.Fatalf("Expected %d, but got %d\n", len(ref), tree.NodeCount())
+	}
+	for i, key := range ref {
+		node := tree.Select(uint64(i))
+		if node == nil || node.Key != key {
+			t.Fatalf("Expected Select(%d) = %f, but got %v\n", i, key, node)
+		}
+		if rank := tree.RankOfKey(key); rank != uint64(sort.SearchFloat64s(ref, key)) {
+			t.Fatalf("Expected RankOfKey(%f) = %d, but got %d\n", key, sort.SearchFloat64s(ref, key), rank)
+		}
+	}
+	for key, list := range nodes {
+		for j, node := range list {
+			if rank := tree.Rank(node); rank != uint64(sort.SearchFloat64s(ref, key)+j) {
+				t.Fatalf("Expected Rank = %d, but got %d\n", sort.SearchFloat64s(ref, key)+j, rank)
+			}
+		}
+	}
+}
+
+func TestFloat64TotalOrder(t *testing.T) {
+	var (
+		negNaN = math.Float64frombits(math.Float64bits(math.NaN()) | 1<<63)
+		keys   = []float64{math.NaN(), 1, math.Inf(1), 0, -1, negNaN, math.Copysign(0, -1), math.Inf(-1)}
+		tree   = BuildFloat64Tree()
+	)
+	for i, key := range keys {
+		tree.Insert(key, i)
+	}
+	var order []interface{}
+	tree.Ascend(0, func(key float64, val interface{}) bool {
+		order = append(order, val)
+		return true
+	})
+	if fmt.Sprint(order) != "[5 7 4 6 3 1 2 0]" {
+		t.Errorf("Expected -NaN -Inf -1 -0 +0 1 +Inf NaN ([5 7 4 6 3 1 2 0]), but got %v\n", order)
+	}
+	if node := tree.LookUp(math.NaN()); node == nil || node.Val != 0 {
+		t.Errorf("Expected to find NaN, but got %v\n", node)
+	}
+	if rank := tree.RankOfKey(0); rank != 4 {
+		t.Errorf("Expected -0 to rank below +0 (4), but got %d\n", rank)
+	}
+}
+
+func TestInt64Tree(t *testing.T) {
+	tree := BuildInt64Tree()
+	for _, key := range rand.New(rand.NewSource(5)).Perm(1000) {
+		tree.Insert(int64(key), nil)
+	}
+	for key := int64(0); key < 1000; key += 2 {
+		tree.Remove(tree.LookUp(key))
+	}
+	var expected int64 = 1
+	for node := tree.Select(0); node != nil; node = tree.NxtNode(node) {
+		if node.Key != expected {
+			t.Fatalf("Expected %d, but got %d\n", expected, node.Key)
+		}
+		expected += 2
+	}
+	if node := tree.PrevNode(tree.Select(tree.NodeCount() - 1)); node.Key != 997 {
+		t.Errorf("Expected 997, but got %d\n", node.Key)
+	}
+	if rank := tree.RankOfKey(500); rank != 250 {
+		t.Errorf("Expected 250, but got %d\n", rank)
+	}
+}